@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mtrentz/stringsim/similarity"
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// clusterCmd represents the cluster command
+var clusterCmd = &cobra.Command{
+	Use:   "cluster [<s1> <s2> ...] -t <threshold> [flags]",
+	Short: "Group near-duplicates of a single list into clusters.",
+	Long: `Group near-duplicates of a single list into clusters. Outputs every distinct string with
+how many times it appeared, the id of its cluster and a canonical representative of the cluster.
+
+Grouping the strings in a txt file whose Jaro score is at least 0.9, directly or through other strings
+  stringsim cluster -f vendors.txt -t 0.9 -o clusters.csv
+
+Grouping with average linkage and picking the most central member as canonical
+  stringsim cluster -f vendors.txt -t 0.85 --linkage average --canonical central
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// First check if nothing was provided, if so
+		// only print the usage message.
+		if len(args) == 0 && ListFile == "" {
+			cmd.Usage()
+			return
+		}
+
+		// Strings come either from the file or the arguments
+		var strs []string
+		if ListFile != "" {
+			strs = utils.ReadFromFile(ListFile)
+		} else {
+			utils.CheckForMinimumArgs(cmd, 2, args)
+			strs = args
+		}
+
+		// Check if output is either a .json or .csv
+		if Output != "" {
+			if ext := utils.FileExt(Output); ext != ".json" && ext != ".csv" {
+				fmt.Println("Output file extension not .json or .csv")
+				os.Exit(1)
+			}
+		}
+
+		Metric = resolveMetric(Metric)
+		Linkage = strings.ToLower(Linkage)
+		Canonical = strings.ToLower(Canonical)
+
+		// The task will be done concurrently
+		// where the amount of goroutines is the smaller of the
+		// number of CPUs and the length of strs
+		amountGoroutines := utils.Min(len(strs), runtime.NumCPU())
+
+		stringFlags := map[string]string{
+			"Output": Output,
+			"Metric": Metric,
+		}
+		boolFlags := map[string]bool{
+			"Insensitive": Insensitive,
+			"Silent":      Silent,
+			"Unidecode":   Unidecode,
+		}
+
+		setMetricOptions()
+		similarity.ClusterFlow(strs, Metric, Threshold, Linkage, Canonical, amountGoroutines, newNormalizer(), stringFlags, boolFlags)
+	},
+}
+
+var Linkage string
+var Canonical string
+
+func init() {
+	rootCmd.AddCommand(clusterCmd)
+
+	clusterCmd.Flags().StringVarP(&ListFile, "file", "f", "", "Path to input file containing the strings to cluster. This can be a .txt file separated by newlines, or a JSON list of strings")
+	clusterCmd.Flags().Float64VarP(&Threshold, "threshold", "t", 0, "Minimum score, or maximum distance for Levenshtein, DamerauLevenshtein, Hamming, the weighted edit distances and NormalizedCompressionDistance, for two strings to be in the same cluster")
+	clusterCmd.Flags().StringVarP(&Linkage, "linkage", "l", "single", "How clusters are joined. Available: Single (connected components of the pairs within the threshold), Average, Complete")
+	clusterCmd.Flags().StringVarP(&Canonical, "canonical", "c", "frequent", "How the representative of each cluster is chosen. Available: Frequent (appears the most times), Central (most similar to the other members)")
+	clusterCmd.Flags().BoolVarP(&Insensitive, "insensitive", "i", false, "Use case insensitive comparison")
+	clusterCmd.Flags().StringVarP(&Output, "out", "o", "", "Path to output file. If not provided, output will be printed to stdout")
+	clusterCmd.Flags().StringVarP(&Metric, "metric", "m", "", "Metric used to compare strings. Defaults to Jaro. Available: "+similarity.MetricNames)
+	clusterCmd.Flags().BoolVarP(&Silent, "silent", "s", false, "If provided, will not print the results to stdout")
+	clusterCmd.Flags().BoolVarP(&Unidecode, "unidecode", "u", false, "If provided, will use unidecode to get ASCII transliterations of Unicode text")
+	clusterCmd.Flags().StringVarP(&Synonyms, "synonyms", "", "", "Path to a csv file of synonyms, one group per row starting with the form to write them as, like international,intl")
+	clusterCmd.Flags().StringArrayVarP(&Normalize, "normalize", "n", nil, "Ordered steps applied to every string before comparing, separated by commas. See stringsim --help for the available steps. Output shows the original strings")
+	clusterCmd.MarkFlagRequired("threshold")
+	addMetricFlags(clusterCmd)
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mtrentz/stringsim/similarity"
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// dedupeCmd represents the dedupe command
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe [<s1> <s2> ...] [flags]",
+	Short: "Find near-duplicates inside a single list of strings.",
+	Long: `Find near-duplicates inside a single list of strings. Every pair is compared only once
+and strings are never compared to themselves.
+
+Finding duplicates among the arguments
+  stringsim dedupe adam adan Aden -i
+
+Finding pairs with a Jaro score of at least 0.9 in a txt file separated by newlines
+  stringsim dedupe -f strings.txt -t 0.9 -o duplicates.csv
+
+Finding pairs with a Levenshtein distance of at most 2
+  stringsim dedupe -f strings.txt -m Levenshtein -t 2
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// First check if nothing was provided, if so
+		// only print the usage message.
+		if len(args) == 0 && ListFile == "" {
+			cmd.Usage()
+			return
+		}
+
+		// Strings come either from the file or the arguments
+		var strs []string
+		if ListFile != "" {
+			strs = utils.ReadFromFile(ListFile)
+		} else {
+			utils.CheckForMinimumArgs(cmd, 2, args)
+			strs = args
+		}
+
+		// Check if output is either a .json or .csv
+		if Output != "" {
+			if ext := utils.FileExt(Output); ext != ".json" && ext != ".csv" {
+				fmt.Println("Output file extension not .json or .csv")
+				os.Exit(1)
+			}
+		}
+
+		Metric = resolveMetric(Metric)
+
+		// Without a threshold every pair is a duplicate
+		if !cmd.Flags().Changed("threshold") {
+			if similarity.IsDistanceMetric(Metric) {
+				Threshold = math.Inf(1)
+			} else {
+				Threshold = math.Inf(-1)
+			}
+		}
+
+		// The task will be done concurrently
+		// where the amount of goroutines is the smaller of the
+		// number of CPUs and the length of strs
+		amountGoroutines := utils.Min(len(strs), runtime.NumCPU())
+
+		stringFlags := map[string]string{
+			"Output": Output,
+			"Metric": Metric,
+		}
+		boolFlags := map[string]bool{
+			"Insensitive": Insensitive,
+			"Silent":      Silent,
+			"Unidecode":   Unidecode,
+		}
+
+		setMetricOptions()
+		similarity.DedupeFlow(strs, Metric, Threshold, amountGoroutines, newNormalizer(), stringFlags, boolFlags)
+	},
+}
+
+var ListFile string
+var Threshold float64
+
+func init() {
+	rootCmd.AddCommand(dedupeCmd)
+
+	dedupeCmd.Flags().StringVarP(&ListFile, "file", "f", "", "Path to input file containing the strings to deduplicate. This can be a .txt file separated by newlines, or a JSON list of strings")
+	dedupeCmd.Flags().Float64VarP(&Threshold, "threshold", "t", 0, "Only output pairs with at least this score, or at most this distance for Levenshtein, DamerauLevenshtein, Hamming, the weighted edit distances and NormalizedCompressionDistance. If not provided, all pairs are output")
+	dedupeCmd.Flags().BoolVarP(&Insensitive, "insensitive", "i", false, "Use case insensitive comparison")
+	dedupeCmd.Flags().StringVarP(&Output, "out", "o", "", "Path to output file. If not provided, output will be printed to stdout")
+	dedupeCmd.Flags().StringVarP(&Metric, "metric", "m", "", "Metric used to compare strings. Defaults to Jaro. Available: "+similarity.MetricNames)
+	dedupeCmd.Flags().BoolVarP(&Silent, "silent", "s", false, "If provided, will not print the results to stdout")
+	dedupeCmd.Flags().BoolVarP(&Unidecode, "unidecode", "u", false, "If provided, will use unidecode to get ASCII transliterations of Unicode text")
+	dedupeCmd.Flags().StringVarP(&Synonyms, "synonyms", "", "", "Path to a csv file of synonyms, one group per row starting with the form to write them as, like international,intl")
+	dedupeCmd.Flags().StringArrayVarP(&Normalize, "normalize", "n", nil, "Ordered steps applied to every string before comparing, separated by commas. See stringsim --help for the available steps. Output shows the original strings")
+	addMetricFlags(dedupeCmd)
+}
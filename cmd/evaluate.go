@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mtrentz/stringsim/similarity"
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// evaluateCmd represents the evaluate command
+var evaluateCmd = &cobra.Command{
+	Use:   "evaluate -f <labeled pairs> [flags]",
+	Short: "Evaluate metrics against pairs labeled as matches or not.",
+	Long: `Evaluate metrics against pairs labeled as matches or not. The pairs are read from a csv file
+with the header s1,s2,is_match or a json list of objects with the same keys, where is_match is
+true/false, yes/no or 1/0.
+
+For each metric reports the area under the ROC and the precision-recall curves, and the threshold
+with the best F1 together with its precision and recall. The precision, recall and F1 at every
+threshold are included when writing to a file.
+
+Comparing Jaro and LevenshteinRatio on the labeled pairs, case insensitive
+  stringsim evaluate -f labeled.csv -m jaro,levenshteinratio -i
+
+Evaluating every metric and writing the results to evaluation.csv and evaluation_curve.csv
+  stringsim evaluate -f labeled.csv -m all -o evaluation.csv
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Check if output is either a .json or .csv
+		if Output != "" {
+			if ext := utils.FileExt(Output); ext != ".json" && ext != ".csv" {
+				fmt.Println("Output file extension not .json or .csv")
+				os.Exit(1)
+			}
+		}
+
+		pairs := readLabeledPairs(ListFile)
+
+		stringFlags := map[string]string{
+			"Output": Output,
+		}
+		boolFlags := map[string]bool{
+			"Insensitive": Insensitive,
+			"Silent":      Silent,
+			"Unidecode":   Unidecode,
+		}
+
+		setMetricOptions()
+		similarity.EvaluateFlow(pairs, resolveMetrics(Metrics), newNormalizer(), stringFlags, boolFlags)
+	},
+}
+
+var Metrics []string
+
+// Reads the pairs labeled as matches or not from a csv or json file.
+func readLabeledPairs(filename string) []similarity.LabeledPair {
+	records := utils.ReadRecordsFromFile(filename)
+	pairs := make([]similarity.LabeledPair, len(records))
+	for i, record := range records {
+		isMatch, err := parseLabel(record["is_match"])
+		if err != nil {
+			fmt.Printf("Error reading %s: pair %d: %v\n", filename, i+1, err)
+			os.Exit(1)
+		}
+		pairs[i] = similarity.LabeledPair{
+			S1:      record["s1"],
+			S2:      record["s2"],
+			IsMatch: isMatch,
+		}
+	}
+	return pairs
+}
+
+// Parses true/false, yes/no and 1/0 as a boolean.
+func parseLabel(label string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	isMatch, err := strconv.ParseBool(strings.TrimSpace(label))
+	if err != nil {
+		return false, fmt.Errorf("is_match %q is not true/false, yes/no or 1/0", label)
+	}
+	return isMatch, nil
+}
+
+// Lower cases every metric, where "all" stands for every metric.
+// Defaults to Jaro.
+func resolveMetrics(metrics []string) []string {
+	if len(metrics) == 0 {
+		return []string{"jaro"}
+	}
+	var resolved []string
+	for _, metric := range metrics {
+		metric = strings.ToLower(metric)
+		if metric == "all" {
+			resolved = append(resolved, similarity.AvailableMetrics()...)
+			continue
+		}
+		resolved = append(resolved, metric)
+	}
+	return resolved
+}
+
+func init() {
+	rootCmd.AddCommand(evaluateCmd)
+
+	evaluateCmd.Flags().StringVarP(&ListFile, "file", "f", "", "Path to the labeled pairs, a .csv file with the header s1,s2,is_match or a JSON list of objects with the same keys")
+	evaluateCmd.Flags().StringSliceVarP(&Metrics, "metric", "m", nil, "Comma separated metrics to evaluate, or 'all' for every metric. Defaults to Jaro. Available: "+similarity.MetricNames)
+	evaluateCmd.Flags().BoolVarP(&Insensitive, "insensitive", "i", false, "Use case insensitive comparison")
+	evaluateCmd.Flags().StringVarP(&Output, "out", "o", "", "Path to output file. If not provided, output will be printed to stdout")
+	evaluateCmd.Flags().BoolVarP(&Silent, "silent", "s", false, "If provided, will not print the results to stdout")
+	evaluateCmd.Flags().BoolVarP(&Unidecode, "unidecode", "u", false, "If provided, will use unidecode to get ASCII transliterations of Unicode text")
+	evaluateCmd.Flags().StringVarP(&Synonyms, "synonyms", "", "", "Path to a csv file of synonyms, one group per row starting with the form to write them as, like international,intl")
+	evaluateCmd.Flags().StringArrayVarP(&Normalize, "normalize", "n", nil, "Ordered steps applied to every string before comparing, separated by commas. See stringsim --help for the available steps")
+	evaluateCmd.MarkFlagRequired("file")
+	addMetricFlags(evaluateCmd)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mtrentz/stringsim/similarity"
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// linkCmd represents the link command
+var linkCmd = &cobra.Command{
+	Use:   "link --f1 <records> --f2 <records> -c <config> [flags]",
+	Short: "Link records made of many fields, each compared with its own metric.",
+	Long: `Link records made of many fields, each compared with its own metric and weight. Records are read
+from a csv file with a header or a json list of objects. The score of a pair of records is the
+weighted mean of the scores of its fields.
+
+The config is a json file listing the fields to compare, for example
+  {
+    "id": "id",
+    "fields": [
+      {"name": "name", "metric": "jaro", "weight": 2, "insensitive": true, "unidecode": true},
+      {"name": "city", "metric": "levenshteinratio", "weight": 1, "normalize": ["casefold", "punctuation"]},
+      {"name": "birth_year", "metric": "levenshteinratio", "weight": 1}
+    ]
+  }
+The metric defaults to Jaro and the weight to 1, while a weight of 0 shows the field without
+counting it. Besides "insensitive" and "unidecode", a field
+can list normalization steps in "normalize", the same as the ones of stringsim --normalize. If "id"
+is not set, records are identified by their compared fields. A field that is empty in either record is missing and left out of
+the score of the pair, so the other fields weigh more.
+
+With --probabilistic the weights are not used. Instead the score of each field falls into one
+of the agreement levels set by "levels", defaulting to [0.95, 0.85], meaning at least 0.95, at
+least 0.85 and below 0.85. How likely each level is among matches and non-matches is estimated
+from all pairs with expectation-maximization, giving each pair a match weight and the
+probability of being a match. A missing field falls into a level of its own, with a weight of 0.
+
+Linking the people in two files, keeping pairs with a combined score of at least 0.85
+  stringsim link --f1 people_one.csv --f2 people_two.json -c fields.json -t 0.85 -o links.csv
+
+Linking the same files with probabilistic linkage, keeping pairs with a match probability of at least 0.9
+  stringsim link --f1 people_one.csv --f2 people_two.json -c fields.json -p -t 0.9 -o links.json
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Check if output is either a .json or .csv
+		if Output != "" {
+			if ext := utils.FileExt(Output); ext != ".json" && ext != ".csv" {
+				fmt.Println("Output file extension not .json or .csv")
+				os.Exit(1)
+			}
+		}
+
+		mainRecords := utils.ReadRecordsFromFile(File1)
+		otherRecords := utils.ReadRecordsFromFile(File2)
+
+		var config similarity.LinkConfig
+		utils.ReadJsonFile(LinkConfigFile, &config)
+
+		// Without a threshold every pair is output
+		if !cmd.Flags().Changed("threshold") {
+			Threshold = math.Inf(-1)
+		}
+
+		// The task will be done concurrently
+		// where the amount of goroutines is the smaller of the
+		// number of CPUs and the amount of other records
+		amountGoroutines := utils.Min(len(otherRecords), runtime.NumCPU())
+
+		stringFlags := map[string]string{
+			"File1":  File1,
+			"File2":  File2,
+			"Output": Output,
+		}
+		boolFlags := map[string]bool{
+			"Silent": Silent,
+		}
+
+		setMetricOptions()
+		if Probabilistic {
+			similarity.ProbabilisticLinkFlow(mainRecords, otherRecords, config, Threshold, Iterations, amountGoroutines, stringFlags, boolFlags)
+			return
+		}
+		similarity.LinkFlow(mainRecords, otherRecords, config, Threshold, amountGoroutines, stringFlags, boolFlags)
+	},
+}
+
+var LinkConfigFile string
+var Probabilistic bool
+var Iterations int
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+
+	linkCmd.Flags().StringVarP(&File1, "f1", "", "", "Path to the first file of records, a .csv file with a header or a JSON list of objects")
+	linkCmd.Flags().StringVarP(&File2, "f2", "", "", "Path to the second file of records, a .csv file with a header or a JSON list of objects")
+	linkCmd.Flags().StringVarP(&LinkConfigFile, "config", "c", "", "Path to the JSON config listing the fields to compare, with their metric, weight and normalization")
+	linkCmd.Flags().Float64VarP(&Threshold, "threshold", "t", 0, "Only output pairs of records with at least this combined score, or this match probability with --probabilistic. If not provided, all pairs are output")
+	linkCmd.Flags().BoolVarP(&Probabilistic, "probabilistic", "p", false, "Use Fellegi-Sunter probabilistic linkage, estimating the weight of each agreement level of each field instead of using the configured weights")
+	linkCmd.Flags().IntVarP(&Iterations, "iterations", "", 100, "Maximum iterations of expectation-maximization with --probabilistic")
+	linkCmd.Flags().StringVarP(&Output, "out", "o", "", "Path to output file. If not provided, output will be printed to stdout")
+	linkCmd.Flags().BoolVarP(&Silent, "silent", "s", false, "If provided, will not print the results to stdout")
+	linkCmd.MarkFlagRequired("f1")
+	linkCmd.MarkFlagRequired("f2")
+	linkCmd.MarkFlagRequired("config")
+	addMetricFlags(linkCmd)
+}
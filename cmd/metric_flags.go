@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/mtrentz/stringsim/similarity"
+	"github.com/mtrentz/stringsim/utils"
+)
+
+var Nicknames string
+var AddressLocales []string
+var AddressTerms string
+var EditCosts = similarity.DefaultEditCosts
+var SubstitutionCosts string
+var AlignmentScores = similarity.DefaultAlignmentScores
+var LCSNormalization string
+var NoAutojunk bool
+var InnerMetric string
+var Symmetric bool
+var Compressor string
+var HammingPolicy string
+
+// Help of --hamming-policy, shared by every command that can use Hamming.
+const hammingPolicyHelp = "What Hamming does with strings of different lengths: skip the pair, reporting how many were skipped, pad the shorter string with spaces, count each missing character as a mismatch, or fail. Commands comparing many metrics leave Hamming out instead of failing. Available: skip, pad, count, fail"
+
+// Adds the options of the metrics to a command, so every
+// command scores the same way for the same flags.
+func addMetricFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&Nicknames, "nicknames", "", "", "Path to a csv file adding equivalent given names for the Person metric, one group per row, like william,bill,will")
+	cmd.Flags().StringSliceVarP(&AddressLocales, "address-locale", "", []string{"en"}, "Comma separated locales whose street types, directionals and unit designators the Address metric knows. Available: de, en, es, fr, pt")
+	cmd.Flags().StringVarP(&AddressTerms, "address-terms", "", "", "Path to a csv file adding words written differently in addresses for the Address metric, one group per row starting with the form to write them as, like st,street,str")
+	cmd.Flags().Float64VarP(&EditCosts.Insert, "insert-cost", "", EditCosts.Insert, "Cost of inserting a character for the weighted edit distances")
+	cmd.Flags().Float64VarP(&EditCosts.Delete, "delete-cost", "", EditCosts.Delete, "Cost of deleting a character for the weighted edit distances")
+	cmd.Flags().Float64VarP(&EditCosts.Substitute, "substitute-cost", "", EditCosts.Substitute, "Cost of substituting a character for the weighted edit distances")
+	cmd.Flags().Float64VarP(&EditCosts.Transpose, "transpose-cost", "", EditCosts.Transpose, "Cost of transposing two characters for WeightedDamerauLevenshtein")
+	cmd.Flags().Float64VarP(&EditCosts.Adjacent, "adjacent-cost", "", EditCosts.Adjacent, "Cost of substituting a character with a key next to it on the keyboard for the weighted edit distances")
+	cmd.Flags().StringVarP(&EditCosts.Keyboard, "keyboard", "", EditCosts.Keyboard, "Keyboard layout of the adjacent keys for the weighted edit distances. Available: qwerty, azerty, qwertz, none")
+	cmd.Flags().StringVarP(&SubstitutionCosts, "substitution-costs", "", "", "Path to a csv file of substitution costs for the weighted edit distances, one per row with the characters, what they're confused with and the cost, like 0,O,0.1 or rn,m,0.2. Costs apply both ways")
+	cmd.Flags().Float64VarP(&AlignmentScores.Match, "match-score", "", AlignmentScores.Match, "Score of aligning equal characters for SmithWaterman, NeedlemanWunsch and Gotoh")
+	cmd.Flags().Float64VarP(&AlignmentScores.Mismatch, "mismatch-score", "", AlignmentScores.Mismatch, "Score of aligning different characters for SmithWaterman, NeedlemanWunsch and Gotoh")
+	cmd.Flags().Float64VarP(&AlignmentScores.Gap, "gap-score", "", AlignmentScores.Gap, "Score of each character aligned to a gap for SmithWaterman and NeedlemanWunsch")
+	cmd.Flags().Float64VarP(&AlignmentScores.GapOpen, "gap-open-score", "", AlignmentScores.GapOpen, "Score of the first character of a gap for Gotoh")
+	cmd.Flags().Float64VarP(&AlignmentScores.GapExtend, "gap-extend-score", "", AlignmentScores.GapExtend, "Score of each character extending a gap for Gotoh")
+	cmd.Flags().StringVarP(&LCSNormalization, "lcs-normalization", "", "max", "Length the LongestCommonSubsequenceRatio and LongestCommonSubstringRatio are divided by, the one of the shorter string, the longer one or their mean. Available: min, max, mean")
+	cmd.Flags().BoolVarP(&NoAutojunk, "no-autojunk", "", false, "Disable the autojunk heuristic of RatcliffObershelp, like difflib.SequenceMatcher(autojunk=False), so characters frequent in s2 can start a match")
+	cmd.Flags().StringVarP(&InnerMetric, "inner-metric", "", "jarowinkler", "Metric MongeElkan scores each pair of words with. Has to score from 0 to 1, like Jaro, JaroWinkler or LevenshteinRatio")
+	cmd.Flags().BoolVarP(&Symmetric, "symmetric", "", false, "Use the symmetric MongeElkan, the mean of the score from s1 to s2 and from s2 to s1")
+	cmd.Flags().StringVarP(&Compressor, "compressor", "", "flate", "Compressor of NormalizedCompressionDistance. Available: flate, gzip, zlib, lzw")
+	cmd.Flags().StringVarP(&HammingPolicy, "hamming-policy", "", "fail", hammingPolicyHelp)
+}
+
+// Passes the options of the metrics to the similarity package,
+// before any string is compared.
+func setMetricOptions() {
+	// Nicknames known to the person metric besides the bundled ones
+	if Nicknames != "" {
+		similarity.AddNicknames(utils.ReadRowsFromCsvFile(Nicknames))
+	}
+
+	// Street types and directionals known to the address metric
+	similarity.SetAddressLocales(AddressLocales)
+	if AddressTerms != "" {
+		similarity.AddAddressTerms(utils.ReadRowsFromCsvFile(AddressTerms))
+	}
+
+	// Costs of the weighted edit distances
+	similarity.SetEditCosts(EditCosts)
+	if SubstitutionCosts != "" {
+		similarity.AddSubstitutionCosts(utils.ReadRowsFromCsvFile(SubstitutionCosts))
+	}
+
+	// Scores of the alignment metrics
+	similarity.SetAlignmentScores(AlignmentScores)
+	similarity.SetLCSNormalization(LCSNormalization)
+	similarity.SetAutojunk(!NoAutojunk)
+	similarity.SetMongeElkan(InnerMetric, Symmetric)
+	similarity.SetNCDCompressor(Compressor)
+	similarity.SetHammingPolicy(HammingPolicy)
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import "testing"
+
+// Every command comparing strings takes the options of the metrics.
+func TestMetricFlags(t *testing.T) {
+	names := []string{"inner-metric", "symmetric", "compressor", "match-score", "gap-open-score", "insert-cost", "keyboard", "substitution-costs", "lcs-normalization", "no-autojunk", "nicknames", "address-locale", "address-terms", "hamming-policy"}
+
+	for _, cmd := range append(rootCmd.Commands(), rootCmd) {
+		if cmd.Name() == "help" || cmd.Name() == "completion" {
+			continue
+		}
+		for _, name := range names {
+			if cmd.Flags().Lookup(name) == nil {
+				t.Errorf("%s has no --%s", cmd.Name(), name)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mtrentz/stringsim/similarity"
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// recommendCmd represents the recommend command
+var recommendCmd = &cobra.Command{
+	Use:   "recommend -f <labeled pairs> [flags]",
+	Short: "Recommend the metric, normalization and threshold that best match labeled pairs.",
+	Long: `Recommend the metric, normalization and threshold that best match labeled pairs. Tries every
+metric with every combination of the normalization steps, which default to lowercase, unidecode,
+punctuation and collapse, ranking the combinations by the best F1 they reach and reporting the
+threshold reaching it with its confusion matrix.
+
+The pairs are read from a csv file with the header s1,s2,is_match or a json list of objects
+with the same keys, where is_match is true/false, yes/no or 1/0.
+
+Recommending a configuration from labeled pairs
+  stringsim recommend -f labeled.csv
+
+Only considering Jaro and LevenshteinRatio, writing the full ranking to a file
+  stringsim recommend -f labeled.csv -m jaro,levenshteinratio -o ranking.csv
+
+Trying every combination of other normalization steps
+  stringsim recommend -f labeled.csv -n casefold,company,stopwords
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Check if output is either a .json or .csv
+		if Output != "" {
+			if ext := utils.FileExt(Output); ext != ".json" && ext != ".csv" {
+				fmt.Println("Output file extension not .json or .csv")
+				os.Exit(1)
+			}
+		}
+
+		pairs := readLabeledPairs(ListFile)
+
+		// Every metric unless told otherwise
+		metrics := similarity.AvailableMetrics()
+		if len(Metrics) > 0 {
+			metrics = resolveMetrics(Metrics)
+		}
+
+		// Each combination of the steps is tried
+		steps := defaultRecommendSteps
+		if len(Normalize) > 0 {
+			steps = utils.SplitSteps(Normalize)
+		}
+
+		stringFlags := map[string]string{
+			"Output": Output,
+		}
+		boolFlags := map[string]bool{
+			"Silent": Silent,
+		}
+
+		setMetricOptions()
+		similarity.RecommendFlow(pairs, metrics, steps, stringFlags, boolFlags)
+	},
+}
+
+// Normalization steps whose combinations are tried when none are given
+var defaultRecommendSteps = []string{"lowercase", "unidecode", "punctuation", "collapse"}
+
+func init() {
+	rootCmd.AddCommand(recommendCmd)
+
+	recommendCmd.Flags().StringVarP(&ListFile, "file", "f", "", "Path to the labeled pairs, a .csv file with the header s1,s2,is_match or a JSON list of objects with the same keys")
+	recommendCmd.Flags().StringSliceVarP(&Metrics, "metric", "m", nil, "Comma separated metrics to consider. Defaults to every metric. Available: "+similarity.MetricNames)
+	recommendCmd.Flags().StringArrayVarP(&Normalize, "normalize", "n", nil, "Steps, separated by commas, whose every combination is tried, applied in the order given. Defaults to lowercase, unidecode, punctuation and collapse. See stringsim --help for the available steps")
+	recommendCmd.Flags().StringVarP(&Output, "out", "o", "", "Path to output file. If not provided, output will be printed to stdout")
+	recommendCmd.Flags().BoolVarP(&Silent, "silent", "s", false, "If provided, will not print the results to stdout")
+	recommendCmd.MarkFlagRequired("file")
+	addMetricFlags(recommendCmd)
+}
@@ -6,8 +6,8 @@ package cmd
 
 import (
 	"fmt"
+	"math"
 	"os"
-	"path/filepath"
 	"runtime"
 	"strings"
 
@@ -26,6 +26,9 @@ var rootCmd = &cobra.Command{
 Comparing s1 to s2
   stringsim adam adan
 
+Comparing strings named like a command, which would run that command, after --
+  stringsim -- help helo
+
 Comparing s1 to s2 and s3, case insensitive, output result to file
   stringsim adam adan Aden -i -o output.csv
 
@@ -34,7 +37,65 @@ Reading s2, s3, ..., from a txt file separated by newlines and comparing to 'ada
 
 Reading many words from a json file (formated as array of strings ["a", "b", ...]) and comparing each to every word in a txt file separated by newlines.
   stringsim --f1 strings_one.json --f2 strings_two.txt
+
+Reading and writing compressed files, gzip (.gz) and zstd (.zst) are supported
+  stringsim adam --f2 strings.txt.gz -o output.csv.zst
+
+Streaming a file too big to fit into memory, reading it in chunks
+  stringsim --f1 strings_one.txt --f2 huge.txt --stream -o output.csv
+
+Writing the full score matrix of every s1 against every s2 as a NumPy file
+  stringsim --f1 strings_one.txt --f2 strings_two.txt --matrix -o matrix.npy
+
+Normalizing before comparing, while showing the original strings in the output
+  stringsim --f1 names_one.txt --f2 names_two.txt -n nfkc,casefold,punctuation,collapse,trim
+
+Matching vendor names without their legal forms, like Inc, GmbH or Ltda
+  stringsim --f1 vendors.txt --f2 invoices.txt -n company
+
+Matching person names, where "Bill Smith" matches "William Smith" and "J. R. Smith" matches "Smith, John Robert"
+  stringsim --f1 customers.txt --f2 donors.txt -m Person --nicknames nicknames.csv
+
+Matching addresses, where house numbers and units have to be equal and "Main Street" matches "Main St."
+  stringsim --f1 customers.txt --f2 deliveries.txt -m Address --address-locale en,es
+
+Writing abbreviations and synonyms the same way, like "intl" as "international"
+  stringsim --f1 vendors.txt --f2 invoices.txt -i --synonyms synonyms.csv
+
+Finding likely typos, where keys next to each other on the keyboard cost less to substitute
+  stringsim hwllo hello jello -m WeightedDamerauLevenshtein --adjacent-cost 0.3
+
+Matching identifiers read by OCR, with cheap substitutions for the characters it confuses
+  stringsim --f1 ocr.txt --f2 ids.txt -m WeightedLevenshtein --keyboard none --substitution-costs ocr_costs.csv
+
+Finding a product name inside long descriptions, with the aligned part of each description
+  stringsim --f1 products.txt --f2 descriptions.txt -m SmithWaterman -i
+
+Finding the longest run of characters each s2 shares with s1, relative to the shorter string
+  stringsim --f1 codes.txt --f2 labels.txt -m LongestCommonSubstringRatio --lcs-normalization min
+
+Scoring like Python's difflib.SequenceMatcher(None, s1, s2).ratio(), to reuse its thresholds
+  stringsim --f1 names.txt --f2 other_names.txt -m RatcliffObershelp
+
+Comparing strings of many words with typos in each word, in any order
+  stringsim "jonh smiht" "smith john" "john smith jr" -m MongeElkan --inner-metric JaroWinkler --symmetric
+
+Comparing long log lines, where edit distances are too slow, by how well they compress together
+  stringsim --f1 errors.txt --f2 logs.txt -m NCD --compressor gzip -o output.csv
+
+Comparing codes with Hamming, leaving out and counting the pairs of different lengths
+  stringsim --f1 codes.txt --f2 scanned.txt -m Hamming --hamming-policy skip
+
+Explaining which edits turn s1 into each s2
+  stringsim kitten sitting mitten -m Levenshtein --explain
+
+Matching each product of last month to at most one of this month, writing the unmatched ones to output_unmatched.csv
+  stringsim --f1 last_month.txt --f2 this_month.txt --assign --min-score 0.8 -o output.csv
 `,
+	// Any argument that isn't a subcommand is a string to compare,
+	// otherwise cobra would report it as an unknown command. A first
+	// string named like a subcommand runs it, unless after "--".
+	Args: cobra.ArbitraryArgs,
 	// Uncomment the following line if your bare application
 	// has an action associated with it:
 	Run: func(cmd *cobra.Command, args []string) {
@@ -55,17 +116,42 @@ Reading many words from a json file (formated as array of strings ["a", "b", ...
 		// Quickly check if any input was provided its either
 		// a txt file or a json extension
 		if File1 != "" {
-			if ext := filepath.Ext(File1); ext != ".json" && ext != ".txt" {
+			if ext := utils.FileExt(File1); ext != ".json" && ext != ".txt" {
 				fmt.Println("File1 extension not .json or .txt")
 				os.Exit(1)
 			}
 		}
 		if File2 != "" {
-			if ext := filepath.Ext(File2); ext != ".json" && ext != ".txt" {
+			if ext := utils.FileExt(File2); ext != ".json" && ext != ".txt" {
 				fmt.Println("File2 extension not .json or .txt")
 				os.Exit(1)
 			}
 		}
+		// Streaming reads the s2s from File2 while writing
+		// the results to the output file, so it needs both.
+		if Stream && (File2 == "" || Output == "") {
+			fmt.Println("Streaming requires --f2 and -o to be provided")
+			os.Exit(1)
+		}
+		// The matrix is held in memory, so it can't be streamed,
+		// and the assignment is calculated from the matrix
+		if Stream && (Matrix || Assign) {
+			fmt.Println("Can't use --matrix or --assign together with --stream")
+			os.Exit(1)
+		}
+		if Matrix && Assign {
+			fmt.Println("Can't use --matrix together with --assign")
+			os.Exit(1)
+		}
+		if ChunkSize < 1 {
+			fmt.Println("Chunk size must be at least 1")
+			os.Exit(1)
+		}
+		if MaxLineLength < 1 {
+			fmt.Println("Max line length must be at least 1")
+			os.Exit(1)
+		}
+		utils.MaxLineLength = MaxLineLength
 		// If File1 was provided, I either need at least
 		// one argument (s2) or File2
 		if File1 != "" {
@@ -73,8 +159,11 @@ Reading many words from a json file (formated as array of strings ["a", "b", ...
 			mainStrings = utils.ReadFromFile(File1)
 			// Check if File2 was provided
 			if File2 != "" {
-				// Read 's2's from the file
-				otherStrings = utils.ReadFromFile(File2)
+				// Read 's2's from the file, unless they
+				// will be streamed later
+				if !Stream {
+					otherStrings = utils.ReadFromFile(File2)
+				}
 			} else {
 				// If File2 was not provided,
 				// then only one argument (s2) needs to be provided
@@ -89,8 +178,11 @@ Reading many words from a json file (formated as array of strings ["a", "b", ...
 				// If File2 was provided, I need at least one argument
 				// to be the s1.
 				utils.CheckForMinimumArgs(cmd, 1, args)
-				// I'll read 's2's from file
-				otherStrings = utils.ReadFromFile(File2)
+				// I'll read 's2's from file, unless they
+				// will be streamed later
+				if !Stream {
+					otherStrings = utils.ReadFromFile(File2)
+				}
 				// And 's1's from the arguments
 				mainStrings = args
 			} else {
@@ -103,32 +195,29 @@ Reading many words from a json file (formated as array of strings ["a", "b", ...
 				otherStrings = args[1:]
 			}
 		}
-		// Check if output is either a .json or .csv
-		if Output != "" {
-			if ext := filepath.Ext(Output); ext != ".json" && ext != ".csv" {
+		// Check if output is either a .json or .csv,
+		// or a .csv or .npy when outputting a matrix
+		if Output != "" && !Matrix {
+			if ext := utils.FileExt(Output); ext != ".json" && ext != ".csv" {
 				fmt.Println("Output file extension not .json or .csv")
 				os.Exit(1)
 			}
 		}
-
-		// Case insensitive and unidecode flags
-		if Insensitive {
-			utils.SliceToLower(&mainStrings)
-			utils.SliceToLower(&otherStrings)
-		}
-		if Unidecode {
-			utils.SliceToUnidecode(&mainStrings)
-			utils.SliceToUnidecode(&otherStrings)
+		if Output != "" && Matrix {
+			if ext := utils.FileExt(Output); ext != ".csv" && ext != ".npy" {
+				fmt.Println("Output file extension not .csv or .npy")
+				os.Exit(1)
+			}
 		}
 
 		// Metric logic
-		// Decide on the metric to use if has a flag
-		if Metric != "" {
-			// Make sure Metric is all lower case
-			Metric = strings.ToLower(Metric)
-		} else {
-			Metric = "jaro"
-		}
+		Metric = resolveMetric(Metric)
+
+		setMetricOptions()
+
+		// Steps applied to every string before comparing it,
+		// applied by the flows so the originals are kept
+		normalizer := newNormalizer()
 
 		// The task will be done concurrently
 		// where the amount of goroutines is the smaller of the
@@ -169,17 +258,76 @@ Reading many words from a json file (formated as array of strings ["a", "b", ...
 			"Insensitive": Insensitive,
 			"Silent":      Silent,
 			"Unidecode":   Unidecode,
+			"Explain":     Explain,
+		}
+
+		// The matrix keeps the input order and is always calculated
+		// in memory, regardless of the amount of computations
+		if Matrix {
+			similarity.MatrixFlow(mainStrings, otherStrings, Metric, amountGoroutines, normalizer, stringFlags, boolFlags)
+			return
+		}
+
+		// The assignment is also calculated from the full matrix.
+		// Without a minimum score any pair can be matched.
+		if Assign {
+			if !cmd.Flags().Changed("min-score") {
+				if similarity.IsDistanceMetric(Metric) {
+					MinScore = math.Inf(1)
+				} else {
+					MinScore = math.Inf(-1)
+				}
+			}
+			boolFlags["Greedy"] = Greedy
+			similarity.AssignFlow(mainStrings, otherStrings, Metric, MinScore, amountGoroutines, normalizer, stringFlags, boolFlags)
+			return
+		}
+
+		// When streaming, the 's2's are read in chunks while the
+		// similarities are calculated, using all the CPUs since
+		// the amount of 's2's isn't known beforehand.
+		if Stream {
+			chunks := utils.StreamFromFile(File2, ChunkSize)
+			similarity.StreamFlow(mainStrings, chunks, Metric, MAX_CPU_CORES, normalizer, stringFlags, boolFlags)
+			return
 		}
 
 		// Send them to the proper flow
 		if !tooManyComputations {
-			similarity.NormalFlow(mainStrings, otherStringsSubSlices, Metric, amountGoroutines, stringFlags, boolFlags)
+			similarity.NormalFlow(mainStrings, otherStringsSubSlices, Metric, amountGoroutines, normalizer, stringFlags, boolFlags)
 		} else {
-			similarity.BigFileFlow(mainStrings, otherStringsSubSlices, Metric, amountGoroutines, stringFlags, boolFlags)
+			similarity.BigFileFlow(mainStrings, otherStringsSubSlices, Metric, amountGoroutines, normalizer, stringFlags, boolFlags)
 		}
 	},
 }
 
+// Decide on the metric to use if has a flag,
+// making sure it's all lower case. Defaults to Jaro.
+func resolveMetric(metric string) string {
+	if metric != "" {
+		return strings.ToLower(metric)
+	}
+	return "jaro"
+}
+
+// The case insensitive and unidecode flags are the first
+// normalization steps, followed by the ones given to --normalize
+// and the synonyms last, to be matched on the normalized words.
+func newNormalizer() *utils.Normalizer {
+	var steps []string
+	if Insensitive {
+		steps = append(steps, "lowercase")
+	}
+	if Unidecode {
+		steps = append(steps, "unidecode")
+	}
+	steps = append(steps, Normalize...)
+	if Synonyms != "" {
+		steps = append(steps, "synonyms:"+Synonyms)
+	}
+	return utils.NewNormalizer(steps)
+}
+
 func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
@@ -195,13 +343,34 @@ var File2 string
 var Output string
 var Metric string
 var Silent bool
+var Stream bool
+var ChunkSize int
+var MaxLineLength int
+var Matrix bool
+var Assign bool
+var MinScore float64
+var Greedy bool
+var Explain bool
+var Normalize []string
+var Synonyms string
 
 func init() {
 	rootCmd.Flags().BoolVarP(&Insensitive, "insensitive", "i", false, "Use case insensitive comparison")
-	rootCmd.Flags().StringVarP(&File1, "f1", "", "", "Path to input file containing many s1, to be compared against all other s2. This can be a .txt file separated by newlines, or a JSON list of strings")
-	rootCmd.Flags().StringVarP(&File2, "f2", "", "", "Path to input file containing many s2, to be compared against s1, many s1 in case f1 was provided. This can be a .txt file separated by newlines, or a JSON list of strings")
-	rootCmd.Flags().StringVarP(&Output, "out", "o", "", "Path to output file. If not provided, output will be printed to stdout")
-	rootCmd.Flags().StringVarP(&Metric, "metric", "m", "", "Metric used to compare strings. Defaults to Jaro. Available: Jaro, Levenshtein, LevenshteinRatio, DamerauLevenshtein, Hamming, LongestCommonSubsequence (LCS)")
+	rootCmd.Flags().StringVarP(&File1, "f1", "", "", "Path to input file containing many s1, to be compared against all other s2. This can be a .txt file separated by newlines, or a JSON list of strings, optionally compressed with gzip or zstd")
+	rootCmd.Flags().StringVarP(&File2, "f2", "", "", "Path to input file containing many s2, to be compared against s1, many s1 in case f1 was provided. This can be a .txt file separated by newlines, or a JSON list of strings, optionally compressed with gzip or zstd")
+	rootCmd.Flags().StringVarP(&Output, "out", "o", "", "Path to output file. If not provided, output will be printed to stdout. Add .gz or .zst to the extension to compress it")
+	rootCmd.Flags().StringVarP(&Metric, "metric", "m", "", "Metric used to compare strings. Defaults to Jaro. Available: "+similarity.MetricNames)
 	rootCmd.Flags().BoolVarP(&Silent, "silent", "s", false, "If provided, will not print the results to stdout")
 	rootCmd.Flags().BoolVarP(&Unidecode, "unidecode", "u", false, "If provided, will use unidecode to get ASCII transliterations of Unicode text")
+	rootCmd.Flags().BoolVarP(&Stream, "stream", "", false, "Read f2 in chunks while calculating, instead of loading it all into memory. Requires --f2 and -o")
+	rootCmd.Flags().IntVarP(&ChunkSize, "chunk-size", "", 1000, "Amount of strings read from f2 at a time when streaming")
+	rootCmd.Flags().BoolVarP(&Matrix, "matrix", "", false, "Output the full score matrix, with a row for each s1 and a column for each s2 in input order. Output file can be .csv or .npy")
+	rootCmd.Flags().BoolVarP(&Assign, "assign", "", false, "Match each s1 to at most one s2 and vice versa, maximizing the total score. Matched and unmatched strings are output separately")
+	rootCmd.Flags().Float64VarP(&MinScore, "min-score", "", 0, "With --assign, minimum score, or maximum distance for Levenshtein, DamerauLevenshtein, Hamming, the weighted edit distances and NormalizedCompressionDistance, for a pair to be matched")
+	rootCmd.Flags().BoolVarP(&Greedy, "greedy", "", false, "With --assign, use the faster greedy matching instead of the optimal one. Always used when a side has more than 2000 strings")
+	rootCmd.Flags().BoolVarP(&Explain, "explain", "e", false, "Explain each score. Shows the edit operations and alignment for Levenshtein, LevenshteinRatio, DamerauLevenshtein, WeightedLevenshtein, WeightedDamerauLevenshtein and Hamming, and the matched characters and transpositions for Jaro, plus any synonyms replaced. Included in stdout and JSON output")
+	rootCmd.Flags().StringArrayVarP(&Normalize, "normalize", "n", nil, "Ordered steps applied to every string before comparing, separated by commas. Available: lowercase, unidecode, nfc, nfkc, casefold, collapse, trim, punctuation, digits, stopwords[:file], company[:file], synonyms:file, regex:pattern=>replacement. A regex step takes the rest of the value. Can be repeated. Applied after -i and -u. Output shows the original strings next to the normalized ones")
+	rootCmd.Flags().StringVarP(&Synonyms, "synonyms", "", "", "Path to a csv file of synonyms, one group per row starting with the form to write them as, like international,intl. Phrases of many words are supported. Replaced after the other normalization steps, with any metric, and reported by --explain")
+	addMetricFlags(rootCmd)
+	rootCmd.Flags().IntVarP(&MaxLineLength, "max-line-length", "", 1024*1024, "Maximum length in bytes of a line read from a .txt file")
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import "testing"
+
+// A first string named like a subcommand runs it, while after
+// "--" every string is compared by the root command.
+func TestRootFindsComparisons(t *testing.T) {
+	// Execute adds the help command, which Find alone doesn't
+	rootCmd.InitDefaultHelpCmd()
+
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"adam", "adan"}, "stringsim"},
+		{[]string{"help", "helo"}, "help"},
+		{[]string{"dedupe", "dedup"}, "dedupe"},
+		{[]string{"--", "help", "helo"}, "stringsim"},
+		{[]string{"--", "dedupe", "dedup"}, "stringsim"},
+		{[]string{"-i", "--", "cluster", "clusters"}, "stringsim"},
+	}
+
+	for _, c := range cases {
+		cmd, _, err := rootCmd.Find(c.args)
+		if err != nil {
+			t.Errorf("Find(%q) returned %v", c.args, err)
+			continue
+		}
+		if cmd.Name() != c.want {
+			t.Errorf("Find(%q) = %s, want %s", c.args, cmd.Name(), c.want)
+		}
+	}
+}
@@ -4,8 +4,10 @@ go 1.18
 
 require (
 	github.com/antzucaro/matchr v0.0.0-20210222213004-b04723ef80f0
+	github.com/klauspost/compress v1.15.9
 	github.com/mozillazg/go-unidecode v0.1.1
 	github.com/spf13/cobra v1.5.0
+	golang.org/x/text v0.14.0
 )
 
 require (
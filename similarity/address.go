@@ -0,0 +1,276 @@
+package similarity
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/antzucaro/matchr"
+	"github.com/mozillazg/go-unidecode"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// Street types, directionals and unit designators of each locale.
+// The first word of each group is the form every word of the
+// group is written as, so "Street" and "Str" both become "st".
+var addressLocales = map[string][][]string{
+	"en": {
+		{"st", "street", "str"},
+		{"ave", "avenue", "av", "avn", "avenu"},
+		{"rd", "road"},
+		{"blvd", "boulevard", "boul", "blv"},
+		{"dr", "drive", "drv"},
+		{"ln", "lane"},
+		{"ct", "court", "crt"},
+		{"pl", "place"},
+		{"sq", "square"},
+		{"ter", "terrace", "terr"},
+		{"pkwy", "parkway", "pky"},
+		{"hwy", "highway"},
+		{"cir", "circle"},
+		{"trl", "trail"},
+		{"aly", "alley"},
+		{"cres", "crescent"},
+		{"mt", "mount"},
+		{"ft", "fort"},
+		{"n", "north"},
+		{"s", "south"},
+		{"e", "east"},
+		{"w", "west"},
+		{"ne", "northeast"},
+		{"nw", "northwest"},
+		{"se", "southeast"},
+		{"sw", "southwest"},
+		{"unit", "apartment", "apt", "suite", "ste", "#", "flat", "room", "rm"},
+		{"fl", "floor"},
+		{"bldg", "building"},
+	},
+	"es": {
+		{"calle", "c", "cl", "cll"},
+		{"av", "avenida", "avda"},
+		{"pza", "plaza", "pl"},
+		{"paseo", "po", "pso"},
+		{"cra", "carrera", "kr"},
+		{"ctra", "carretera"},
+		{"cno", "camino"},
+		{"n", "norte"},
+		{"s", "sur"},
+		{"e", "este"},
+		{"o", "oeste"},
+		{"unit", "departamento", "dpto", "depto", "apartamento", "apto", "#"},
+		{"piso", "planta"},
+	},
+	"pt": {
+		{"r", "rua"},
+		{"av", "avenida", "avda"},
+		{"tv", "travessa", "trav"},
+		{"pca", "praca", "pc"},
+		{"al", "alameda"},
+		{"estr", "estrada"},
+		{"rod", "rodovia"},
+		{"lgo", "largo", "lg"},
+		{"n", "norte"},
+		{"s", "sul"},
+		{"l", "leste"},
+		{"o", "oeste"},
+		{"unit", "apartamento", "apto", "ap", "apt", "#"},
+		{"bl", "bloco"},
+		{"cj", "conjunto"},
+	},
+	"fr": {
+		{"r", "rue"},
+		{"av", "avenue", "ave"},
+		{"bd", "boulevard", "boul", "blvd"},
+		{"pl", "place"},
+		{"ch", "chemin", "chem"},
+		{"rte", "route"},
+		{"imp", "impasse"},
+		{"all", "allee"},
+		{"qu", "quai"},
+		{"fg", "faubourg", "fbg"},
+		{"unit", "appartement", "app", "apt", "#"},
+		{"bat", "batiment"},
+	},
+	"de": {
+		{"str", "strasse", "strase"},
+		{"pl", "platz"},
+		{"weg", "wg"},
+		{"allee", "al"},
+		{"ring", "rg"},
+		{"unit", "wohnung", "whg", "#"},
+		{"og", "obergeschoss", "stock"},
+	},
+}
+
+// Endings of compound words of each locale, written the same way as the
+// words of addressLocales, so "Hauptstraße" becomes "haupt str" just
+// like "Haupt Str.".
+var addressCompoundEndings = map[string][][]string{
+	"de": {
+		{"str", "strasse", "str"},
+		{"pl", "platz"},
+		{"weg", "weg"},
+		{"allee", "allee"},
+	},
+}
+
+// How each word is written, for the locales in use.
+var addressTerms map[string]string
+var addressEndings map[string]string
+
+func init() {
+	SetAddressLocales([]string{"en"})
+}
+
+// Sets the locales whose street types, directionals and unit designators
+// the address metric knows, replacing the previous ones. When locales
+// write a word differently, the first locale given wins. Exits on an
+// unknown locale.
+func SetAddressLocales(locales []string) {
+	addressTerms = make(map[string]string)
+	addressEndings = make(map[string]string)
+	for i := len(locales) - 1; i >= 0; i-- {
+		locale := strings.ToLower(strings.TrimSpace(locales[i]))
+		groups, ok := addressLocales[locale]
+		if !ok {
+			fmt.Printf("Address locale %s not supported. Available: de, en, es, fr, pt\n", locales[i])
+			os.Exit(1)
+		}
+		AddAddressTerms(groups)
+		for _, group := range addressCompoundEndings[locale] {
+			for _, ending := range group[1:] {
+				addressEndings[ending] = group[0]
+			}
+		}
+	}
+}
+
+// Adds groups of words written differently in addresses, like the
+// rows of a csv file read with utils.ReadRowsFromCsvFile. The first
+// word of a group is the form all of them are written as.
+func AddAddressTerms(groups [][]string) {
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+		canonical := strings.ToLower(unidecode.Unidecode(strings.TrimSpace(group[0])))
+		for _, word := range group {
+			addressTerms[strings.ToLower(unidecode.Unidecode(strings.TrimSpace(word)))] = canonical
+		}
+	}
+}
+
+// Splits an address into the words and the numbers it has, with every
+// word written the same way. Any word with a digit, like "221b" or
+// "5th", is a number.
+func parseAddress(s string) ([]string, []string) {
+	s = strings.ToLower(unidecode.Unidecode(s))
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '.' || r == '\'':
+			continue
+		case r == '#':
+			b.WriteString(" # ")
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	var words []string
+	var numbers []string
+	for _, token := range strings.Fields(b.String()) {
+		if strings.IndexFunc(token, unicode.IsDigit) >= 0 {
+			numbers = append(numbers, token)
+			continue
+		}
+		if term, ok := addressTerms[token]; ok {
+			words = append(words, term)
+			continue
+		}
+		words = append(words, splitAddressCompound(token)...)
+	}
+	return words, numbers
+}
+
+// Splits a compound word with a known ending, like "hauptstrasse",
+// into its beginning and the ending written as a term. The longest
+// ending wins when more than one matches.
+func splitAddressCompound(token string) []string {
+	longest := ""
+	for ending := range addressEndings {
+		if len(ending) > len(longest) && len(token) > len(ending) && strings.HasSuffix(token, ending) {
+			longest = ending
+		}
+	}
+	if longest == "" {
+		return []string{token}
+	}
+	return []string{token[:len(token)-len(longest)], addressEndings[longest]}
+}
+
+// Scores two addresses from 0 to 1. The numbers, like the house number
+// and the unit, have to be equal, in any order, while the words are
+// compared fuzzily, also in any order. The score is the share of the
+// numbers both have times the similarity of the words.
+func addressSimilarity(s1 string, s2 string) float64 {
+	words1, numbers1 := parseAddress(s1)
+	words2, numbers2 := parseAddress(s2)
+
+	words := wordsSimilarity(words1, words2)
+
+	switch {
+	case len(numbers1) == 0 && len(numbers2) == 0:
+		return words
+	case len(numbers1) == 0 || len(numbers2) == 0:
+		// Numbers missing on one side, which could be the same place
+		// without its house number or a whole street
+		return words * 0.8
+	}
+
+	// Share of the numbers both have, counting repeats
+	count := make(map[string]int)
+	for _, n := range numbers1 {
+		count[n]++
+	}
+	common := 0
+	for _, n := range numbers2 {
+		if count[n] > 0 {
+			count[n]--
+			common++
+		}
+	}
+	return words * float64(common) / float64(utils.Max(len(numbers1), len(numbers2)))
+}
+
+// Mean, over the words of both sides, of the Jaro score with the
+// most similar word of the other side, so the order doesn't matter.
+func wordsSimilarity(words1 []string, words2 []string) float64 {
+	if len(words1) == 0 && len(words2) == 0 {
+		return 1
+	}
+	if len(words1) == 0 || len(words2) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, side := range [2][2][]string{{words1, words2}, {words2, words1}} {
+		for _, w1 := range side[0] {
+			best := 0.0
+			for _, w2 := range side[1] {
+				score := 1.0
+				if w1 != w2 {
+					score = matchr.Jaro(w1, w2)
+				}
+				if score > best {
+					best = score
+				}
+			}
+			total += best
+		}
+	}
+	return total / float64(len(words1)+len(words2))
+}
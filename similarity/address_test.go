@@ -0,0 +1,64 @@
+package similarity
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	cases := []struct {
+		s       string
+		words   []string
+		numbers []string
+	}{
+		{"123 Main Street", []string{"main", "st"}, []string{"123"}},
+		{"123 Main St. Apt 4", []string{"main", "st", "unit"}, []string{"123", "4"}},
+		{"221B Baker Street", []string{"baker", "st"}, []string{"221b"}},
+		{"North Main Street", []string{"n", "main", "st"}, nil},
+		{"", nil, nil},
+	}
+
+	for _, c := range cases {
+		words, numbers := parseAddress(c.s)
+		if !reflect.DeepEqual(words, c.words) || !reflect.DeepEqual(numbers, c.numbers) {
+			t.Errorf("parseAddress(%q) = %q, %q, want %q, %q", c.s, words, numbers, c.words, c.numbers)
+		}
+	}
+}
+
+func TestAddressSimilarity(t *testing.T) {
+	defer SetAddressLocales([]string{"en"})
+
+	cases := []struct {
+		locales []string
+		s1      string
+		s2      string
+		want    float64
+	}{
+		{[]string{"en"}, "123 Main Street", "123 Main St.", 1},
+		{[]string{"en"}, "123 Main St Apt 4", "Apt 4, 123 Main Street", 1},
+		{[]string{"en"}, "North Main Street", "N Main St", 1},
+		// House numbers have to be equal
+		{[]string{"en"}, "123 Main St", "124 Main St", 0},
+		{[]string{"en"}, "123 Main St", "Main St", 0.8},
+		{[]string{"en"}, "", "", 1},
+		{[]string{"de", "es"}, "Hauptstraße 5", "Haupt Str. 5", 1},
+		{[]string{"de", "es"}, "Avenida Paulista 1000", "Av. Paulista 1000", 1},
+	}
+
+	for _, c := range cases {
+		SetAddressLocales(c.locales)
+		if got := addressSimilarity(c.s1, c.s2); math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("addressSimilarity(%q, %q) with %q = %v, want %v", c.s1, c.s2, c.locales, got, c.want)
+		}
+	}
+}
+
+// A missing unit costs the share of the numbers only one side has.
+func TestAddressSimilarityMissingUnit(t *testing.T) {
+	words := wordsSimilarity([]string{"main", "st", "unit"}, []string{"main", "st"})
+	if got, want := addressSimilarity("123 Main St Apt 4", "123 Main St"), words/2; math.Abs(got-want) > 1e-12 {
+		t.Errorf("addressSimilarity with a missing unit = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,254 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"os"
+)
+
+// Scores of the alignment metrics. Matches add to the score, while
+// mismatches and gaps take from it. Gotoh charges a gap of k characters
+// GapOpen plus k-1 times GapExtend, instead of k times Gap.
+type AlignmentScores struct {
+	Match     float64
+	Mismatch  float64
+	Gap       float64
+	GapOpen   float64
+	GapExtend float64
+}
+
+var DefaultAlignmentScores = AlignmentScores{
+	Match:     1,
+	Mismatch:  -1,
+	Gap:       -1,
+	GapOpen:   -1,
+	GapExtend: -0.5,
+}
+
+var alignmentScores AlignmentScores
+
+func init() {
+	SetAlignmentScores(DefaultAlignmentScores)
+}
+
+// Sets the scores of the alignment metrics. Exits if a match doesn't
+// score above 0 or a mismatch or gap scores above 0.
+func SetAlignmentScores(scores AlignmentScores) {
+	if scores.Match <= 0 {
+		fmt.Println("Match score has to be above 0")
+		os.Exit(1)
+	}
+	if scores.Mismatch > 0 || scores.Gap > 0 || scores.GapOpen > 0 || scores.GapExtend > 0 {
+		fmt.Println("Mismatch and gap scores can't be above 0")
+		os.Exit(1)
+	}
+	alignmentScores = scores
+}
+
+// Part of each string that was aligned, as rune offsets where the
+// end is exclusive, and the score of the alignment before it was
+// normalized.
+type AlignedRegion struct {
+	Start1   int     `json:"start1"`
+	End1     int     `json:"end1"`
+	Start2   int     `json:"start2"`
+	End2     int     `json:"end2"`
+	RawScore float64 `json:"raw_score"`
+	// Only set for the longest common substring metrics
+	Substring string `json:"substring,omitempty"`
+}
+
+// Metrics that align the strings, also returning the aligned region.
+func IsAlignmentMetric(metric string) bool {
+	switch metric {
+	case "smithwaterman", "needlemanwunsch", "gotoh":
+		return true
+	default:
+		return false
+	}
+}
+
+// Func that receives the metric name and returns a function aligning
+// two strings, which returns the normalized score and the region.
+func getAlignmentFunc(metric string) func(string, string) (float64, *AlignedRegion) {
+	switch metric {
+	case "smithwaterman":
+		return smithWaterman
+	case "needlemanwunsch":
+		return needlemanWunsch
+	case "gotoh":
+		return gotoh
+	default:
+		return nil
+	}
+}
+
+func matchScore(c1 rune, c2 rune) float64 {
+	if c1 == c2 {
+		return alignmentScores.Match
+	}
+	return alignmentScores.Mismatch
+}
+
+// Local alignment score divided by the best possible one, all
+// characters of the shorter string matching, going from 0 to 1.
+func normalizeLocalScore(raw float64, r1 []rune, r2 []rune) float64 {
+	shorter := len(r1)
+	if len(r2) < shorter {
+		shorter = len(r2)
+	}
+	if shorter == 0 {
+		if len(r1) == len(r2) {
+			return 1
+		}
+		return 0
+	}
+	return raw / (alignmentScores.Match * float64(shorter))
+}
+
+// Smith-Waterman local alignment, finding the most similar part of
+// both strings, like a short name inside a long description. The score
+// is normalized by the best possible one for the shorter string.
+func smithWaterman(s1 string, s2 string) (float64, *AlignedRegion) {
+	r1, r2 := []rune(s1), []rune(s2)
+
+	h := make([][]float64, len(r1)+1)
+	for i := range h {
+		h[i] = make([]float64, len(r2)+1)
+	}
+	best, bestI, bestJ := 0.0, 0, 0
+	for i := 1; i <= len(r1); i++ {
+		for j := 1; j <= len(r2); j++ {
+			h[i][j] = math.Max(
+				math.Max(0, h[i-1][j-1]+matchScore(r1[i-1], r2[j-1])),
+				math.Max(h[i-1][j]+alignmentScores.Gap, h[i][j-1]+alignmentScores.Gap),
+			)
+			if h[i][j] > best {
+				best, bestI, bestJ = h[i][j], i, j
+			}
+		}
+	}
+
+	// Backtrack from the best cell until the alignment starts
+	i, j := bestI, bestJ
+	for i > 0 && j > 0 && h[i][j] > 0 {
+		switch h[i][j] {
+		case h[i-1][j-1] + matchScore(r1[i-1], r2[j-1]):
+			i, j = i-1, j-1
+		case h[i-1][j] + alignmentScores.Gap:
+			i--
+		default:
+			j--
+		}
+	}
+
+	region := &AlignedRegion{Start1: i, End1: bestI, Start2: j, End2: bestJ, RawScore: best}
+	return normalizeLocalScore(best, r1, r2), region
+}
+
+// Needleman-Wunsch global alignment of the whole strings. The score is
+// divided by the best possible one for the longer string, all of its
+// characters matching, and floored at 0.
+func needlemanWunsch(s1 string, s2 string) (float64, *AlignedRegion) {
+	r1, r2 := []rune(s1), []rune(s2)
+
+	// Only the previous row is needed
+	prev := make([]float64, len(r2)+1)
+	cur := make([]float64, len(r2)+1)
+	for j := 1; j <= len(r2); j++ {
+		prev[j] = float64(j) * alignmentScores.Gap
+	}
+	for i := 1; i <= len(r1); i++ {
+		cur[0] = float64(i) * alignmentScores.Gap
+		for j := 1; j <= len(r2); j++ {
+			cur[j] = math.Max(
+				prev[j-1]+matchScore(r1[i-1], r2[j-1]),
+				math.Max(prev[j]+alignmentScores.Gap, cur[j-1]+alignmentScores.Gap),
+			)
+		}
+		prev, cur = cur, prev
+	}
+	raw := prev[len(r2)]
+
+	region := &AlignedRegion{End1: len(r1), End2: len(r2), RawScore: raw}
+	longer := len(r1)
+	if len(r2) > longer {
+		longer = len(r2)
+	}
+	if longer == 0 {
+		return 1, region
+	}
+	return math.Max(0, raw/(alignmentScores.Match*float64(longer))), region
+}
+
+// Gotoh's local alignment with affine gaps, where opening a gap costs
+// more than extending it, so one long gap is better than many short
+// ones. Normalized like Smith-Waterman.
+func gotoh(s1 string, s2 string) (float64, *AlignedRegion) {
+	r1, r2 := []rune(s1), []rune(s2)
+
+	// Best score of an alignment ending at i, j with the characters
+	// aligned (h), with a gap in s1 (e) or with a gap in s2 (f)
+	inf := math.Inf(-1)
+	h := make([][]float64, len(r1)+1)
+	e := make([][]float64, len(r1)+1)
+	f := make([][]float64, len(r1)+1)
+	for i := range h {
+		h[i] = make([]float64, len(r2)+1)
+		e[i] = make([]float64, len(r2)+1)
+		f[i] = make([]float64, len(r2)+1)
+		for j := range h[i] {
+			e[i][j], f[i][j] = inf, inf
+		}
+	}
+
+	best, bestI, bestJ := 0.0, 0, 0
+	for i := 1; i <= len(r1); i++ {
+		for j := 1; j <= len(r2); j++ {
+			e[i][j] = math.Max(h[i][j-1]+alignmentScores.GapOpen, e[i][j-1]+alignmentScores.GapExtend)
+			f[i][j] = math.Max(h[i-1][j]+alignmentScores.GapOpen, f[i-1][j]+alignmentScores.GapExtend)
+			h[i][j] = math.Max(
+				math.Max(0, h[i-1][j-1]+matchScore(r1[i-1], r2[j-1])),
+				math.Max(e[i][j], f[i][j]),
+			)
+			if h[i][j] > best {
+				best, bestI, bestJ = h[i][j], i, j
+			}
+		}
+	}
+
+	// Backtrack from the best cell, keeping track of
+	// which of the matrices the alignment is in
+	i, j := bestI, bestJ
+	state := 'h'
+	for i > 0 && j > 0 {
+		if state == 'h' {
+			if h[i][j] <= 0 {
+				break
+			}
+			switch h[i][j] {
+			case h[i-1][j-1] + matchScore(r1[i-1], r2[j-1]):
+				i, j = i-1, j-1
+			case e[i][j]:
+				state = 'e'
+			default:
+				state = 'f'
+			}
+			continue
+		}
+		if state == 'e' {
+			if e[i][j] == h[i][j-1]+alignmentScores.GapOpen {
+				state = 'h'
+			}
+			j--
+			continue
+		}
+		if f[i][j] == h[i-1][j]+alignmentScores.GapOpen {
+			state = 'h'
+		}
+		i--
+	}
+
+	region := &AlignedRegion{Start1: i, End1: bestI, Start2: j, End2: bestJ, RawScore: best}
+	return normalizeLocalScore(best, r1, r2), region
+}
@@ -0,0 +1,57 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAlignmentMetrics(t *testing.T) {
+	cases := []struct {
+		metric string
+		s1     string
+		s2     string
+		want   float64
+		region AlignedRegion
+	}{
+		// The shorter string found inside the longer one
+		{"smithwaterman", "abc", "xxabcxx", 1, AlignedRegion{Start1: 0, End1: 3, Start2: 2, End2: 5, RawScore: 3}},
+		{"smithwaterman", "abcdef", "abcxxdef", 4.0 / 6, AlignedRegion{Start1: 0, End1: 6, Start2: 0, End2: 8, RawScore: 4}},
+		{"smithwaterman", "abc", "xyz", 0, AlignedRegion{}},
+		{"smithwaterman", "", "", 1, AlignedRegion{}},
+		{"smithwaterman", "", "abc", 0, AlignedRegion{}},
+		// The whole strings, floored at 0
+		{"needlemanwunsch", "abc", "abc", 1, AlignedRegion{End1: 3, End2: 3, RawScore: 3}},
+		{"needlemanwunsch", "abc", "abd", 1.0 / 3, AlignedRegion{End1: 3, End2: 3, RawScore: 1}},
+		{"needlemanwunsch", "ab", "abcd", 0, AlignedRegion{End1: 2, End2: 4, RawScore: 0}},
+		{"needlemanwunsch", "abc", "", 0, AlignedRegion{End1: 3, RawScore: -3}},
+		{"needlemanwunsch", "", "", 1, AlignedRegion{}},
+		// A gap of 2 costs 1.5 instead of 2
+		{"gotoh", "abcdef", "abcxxdef", 4.5 / 6, AlignedRegion{Start1: 0, End1: 6, Start2: 0, End2: 8, RawScore: 4.5}},
+		{"gotoh", "abc", "xxabcxx", 1, AlignedRegion{Start1: 0, End1: 3, Start2: 2, End2: 5, RawScore: 3}},
+		{"gotoh", "abc", "xyz", 0, AlignedRegion{}},
+	}
+
+	for _, c := range cases {
+		got, region := getAlignmentFunc(c.metric)(c.s1, c.s2)
+		if math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("%s(%q, %q) = %v, want %v", c.metric, c.s1, c.s2, got, c.want)
+		}
+		if *region != c.region {
+			t.Errorf("%s(%q, %q) aligned %+v, want %+v", c.metric, c.s1, c.s2, *region, c.region)
+		}
+	}
+}
+
+func TestAlignmentScores(t *testing.T) {
+	defer SetAlignmentScores(DefaultAlignmentScores)
+
+	scores := DefaultAlignmentScores
+	scores.Match = 2
+	scores.Gap = -0.5
+	SetAlignmentScores(scores)
+
+	// Two matches, a gap and two matches, over the best of 4 matches
+	if got, _ := smithWaterman("abcd", "abxcd"); math.Abs(got-7.5/8) > 1e-12 {
+		t.Errorf("smithWaterman with a match of 2 and a gap of -0.5 = %v, want %v", got, 7.5/8)
+	}
+}
@@ -0,0 +1,269 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// Above this many strings on the larger side the Hungarian
+// algorithm, which takes O(n^3) time, is replaced by the greedy one.
+const maxHungarianSize = 2000
+
+type Assignment struct {
+	Matched     []Similarity `json:"matched"`
+	UnmatchedS1 []string     `json:"unmatched_s1"`
+	UnmatchedS2 []string     `json:"unmatched_s2"`
+}
+
+// Flow for matching every s1 to at most one s2 and vice versa, with
+// the highest total score, so a string can be left unmatched when its
+// only pair would take better ones away. Pairs that don't pass the
+// minimum score are never matched. Distances, and scores that can go
+// below 0, are shifted by a single constant first, which favors
+// matching more pairs. Outputs the matched pairs in the order of the
+// s1s and the unmatched strings of each side separately.
+func AssignFlow(mainStrings []string, otherStrings []string, metric string, minScore float64, amountGoroutines int, normalizer *utils.Normalizer, StringFlags map[string]string, BoolFlags map[string]bool) {
+	// Pairs are output with the original strings
+	mainNormalized := normalizer.NormalizeSlice(mainStrings)
+	otherNormalized := normalizer.NormalizeSlice(otherStrings)
+	matrix := calculateMatrix(mainNormalized, otherNormalized, metric, amountGoroutines)
+
+	// The weight of a pair is its score, negating distances so a
+	// higher weight is always better. Pairs below the minimum weigh 0,
+	// the same as leaving both strings unmatched, so they are only
+	// assigned when nothing else is left and are dropped afterwards.
+	closeness := func(score float64) float64 {
+		if IsDistanceMetric(metric) {
+			return -score
+		}
+		return score
+	}
+	// Weights below 0 would never be worth matching, so when the worst
+	// pair that passes is below 0, every weight is shifted to make it
+	// weigh 1. Otherwise the total of the scores themselves is maximized.
+	shift := 0.0
+	for i := range matrix {
+		for _, score := range matrix[i] {
+			if passesThreshold(metric, score, minScore) && closeness(score) < 0 {
+				shift = math.Max(shift, 1-closeness(score))
+			}
+		}
+	}
+	weights := make([][]float64, len(mainStrings))
+	for i := range matrix {
+		weights[i] = make([]float64, len(otherStrings))
+		for j, score := range matrix[i] {
+			if passesThreshold(metric, score, minScore) {
+				weights[i][j] = closeness(score) + shift
+			}
+		}
+	}
+
+	var pairs [][2]int
+	if utils.Max(len(mainStrings), len(otherStrings)) > maxHungarianSize || BoolFlags["Greedy"] {
+		pairs = greedyAssignment(weights)
+	} else {
+		pairs = hungarianAssignment(weights)
+	}
+
+	var assignment Assignment
+	matchedS1 := make([]bool, len(mainStrings))
+	matchedS2 := make([]bool, len(otherStrings))
+	for _, pair := range pairs {
+		i, j := pair[0], pair[1]
+		if !passesThreshold(metric, matrix[i][j], minScore) {
+			continue
+		}
+		matchedS1[i] = true
+		matchedS2[j] = true
+		assignment.Matched = append(assignment.Matched, Similarity{
+			Metric: getPrettyMetricName(metric),
+			S1:     mainStrings[i],
+			S2:     otherStrings[j],
+			Score:  matrix[i][j],
+
+			S1Normalized: mainNormalized[i],
+			S2Normalized: otherNormalized[j],
+		})
+	}
+	for i, s1 := range mainStrings {
+		if !matchedS1[i] {
+			assignment.UnmatchedS1 = append(assignment.UnmatchedS1, s1)
+		}
+	}
+	for j, s2 := range otherStrings {
+		if !matchedS2[j] {
+			assignment.UnmatchedS2 = append(assignment.UnmatchedS2, s2)
+		}
+	}
+
+	// Unmatched strings as a table with the side they came from
+	header := []string{"side", "string"}
+	var rows [][]string
+	for _, s1 := range assignment.UnmatchedS1 {
+		rows = append(rows, []string{"s1", s1})
+	}
+	for _, s2 := range assignment.UnmatchedS2 {
+		rows = append(rows, []string{"s2", s2})
+	}
+
+	// Now check if its not set to silent to print results
+	if !BoolFlags["Silent"] {
+		printResults(assignment.Matched)
+		if len(rows) > 0 {
+			fmt.Println()
+			printTable(header, rows)
+		}
+	}
+
+	// Check if output to write to file. A json holds everything
+	// in one object, while a csv only holds the matched pairs
+	// and the unmatched strings go to a second csv file.
+	if StringFlags["Output"] != "" {
+		if utils.FileExt(StringFlags["Output"]) == ".json" {
+			writeTableToFile(StringFlags["Output"], assignment, nil, nil)
+		} else {
+			writeToFile(StringFlags["Output"], assignment.Matched)
+			writeTableToFile(utils.AddFileSuffix(StringFlags["Output"], "_unmatched"), nil, header, rows)
+		}
+	}
+}
+
+// Solves the maximum weight assignment with the Hungarian algorithm,
+// as the minimum cost assignment of the negated weights. Returns
+// the [row, column] of each assigned pair, sorted by row.
+func hungarianAssignment(weights [][]float64) [][2]int {
+	n := len(weights)
+	if n == 0 || len(weights[0]) == 0 {
+		return nil
+	}
+	m := len(weights[0])
+
+	// The algorithm needs at most as many rows as columns,
+	// so transpose if there are more rows.
+	transposed := n > m
+	cost := func(i, j int) float64 {
+		if transposed {
+			return -weights[j][i]
+		}
+		return -weights[i][j]
+	}
+	if transposed {
+		n, m = m, n
+	}
+
+	// Potentials of the rows and columns, the row assigned
+	// to each column and the path of the augmenting search,
+	// all indexed from 1 with 0 as a sentinel column.
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)
+	way := make([]int, m+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		// Grow the alternating tree until a free column is reached
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := cost(i0-1, j-1) - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		// Flip the augmenting path
+		for {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+			if j0 == 0 {
+				break
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for j := 1; j <= m; j++ {
+		if p[j] == 0 {
+			continue
+		}
+		if transposed {
+			pairs = append(pairs, [2]int{j - 1, p[j] - 1})
+		} else {
+			pairs = append(pairs, [2]int{p[j] - 1, j - 1})
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool {
+		return pairs[a][0] < pairs[b][0]
+	})
+	return pairs
+}
+
+// Approximates the maximum weight assignment by taking the pairs
+// from the highest weight down, skipping any row or column that
+// was already taken. Returns the pairs sorted by row.
+func greedyAssignment(weights [][]float64) [][2]int {
+	// Only the pairs with a positive weight can be matched
+	var candidates [][2]int
+	for i := range weights {
+		for j := range weights[i] {
+			if weights[i][j] > 0 {
+				candidates = append(candidates, [2]int{i, j})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return weights[candidates[a][0]][candidates[a][1]] > weights[candidates[b][0]][candidates[b][1]]
+	})
+
+	var pairs [][2]int
+	takenRows := make(map[int]bool)
+	takenCols := make(map[int]bool)
+	for _, c := range candidates {
+		if takenRows[c[0]] || takenCols[c[1]] {
+			continue
+		}
+		takenRows[c[0]] = true
+		takenCols[c[1]] = true
+		pairs = append(pairs, c)
+	}
+	sort.Slice(pairs, func(a, b int) bool {
+		return pairs[a][0] < pairs[b][0]
+	})
+	return pairs
+}
@@ -0,0 +1,88 @@
+package similarity
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// Greedy takes the best pair first, which can take a better total
+// away, while the Hungarian algorithm maximizes the total weight.
+func TestAssignment(t *testing.T) {
+	cases := []struct {
+		weights   [][]float64
+		hungarian [][2]int
+		greedy    [][2]int
+	}{
+		{[][]float64{{0.9, 0.8}, {0.85, 0}}, [][2]int{{0, 1}, {1, 0}}, [][2]int{{0, 0}}},
+		{[][]float64{{1, 0}, {0, 1}}, [][2]int{{0, 0}, {1, 1}}, [][2]int{{0, 0}, {1, 1}}},
+		// More rows than columns, and more columns than rows
+		{[][]float64{{0.1}, {0.9}, {0.5}}, [][2]int{{1, 0}}, [][2]int{{1, 0}}},
+		{[][]float64{{0.1, 0.9, 0.5}}, [][2]int{{0, 1}}, [][2]int{{0, 1}}},
+		{[][]float64{{0.5, 0.6, 0}, {0.7, 0.9, 0.1}}, [][2]int{{0, 0}, {1, 1}}, [][2]int{{0, 0}, {1, 1}}},
+		{nil, nil, nil},
+		{[][]float64{{}}, nil, nil},
+	}
+
+	for _, c := range cases {
+		if got := hungarianAssignment(c.weights); !reflect.DeepEqual(got, c.hungarian) {
+			t.Errorf("hungarianAssignment(%v) = %v, want %v", c.weights, got, c.hungarian)
+		}
+		if got := greedyAssignment(c.weights); !reflect.DeepEqual(got, c.greedy) {
+			t.Errorf("greedyAssignment(%v) = %v, want %v", c.weights, got, c.greedy)
+		}
+	}
+}
+
+// The Hungarian total is the best of every possible assignment,
+// and never below the greedy one.
+func TestHungarianIsOptimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 200; trial++ {
+		n, m := 1+random.Intn(5), 1+random.Intn(5)
+		weights := make([][]float64, n)
+		for i := range weights {
+			weights[i] = make([]float64, m)
+			for j := range weights[i] {
+				weights[i][j] = math.Round(random.Float64()*100) / 100
+			}
+		}
+
+		hungarian := totalWeight(weights, hungarianAssignment(weights))
+		greedy := totalWeight(weights, greedyAssignment(weights))
+		best := bestTotalWeight(weights, 0, make([]bool, m))
+		if math.Abs(hungarian-best) > 1e-9 {
+			t.Errorf("hungarianAssignment(%v) totals %v, want %v", weights, hungarian, best)
+		}
+		if greedy > hungarian+1e-9 {
+			t.Errorf("greedyAssignment(%v) totals %v, above the Hungarian %v", weights, greedy, hungarian)
+		}
+	}
+}
+
+func totalWeight(weights [][]float64, pairs [][2]int) float64 {
+	total := 0.0
+	for _, pair := range pairs {
+		total += weights[pair[0]][pair[1]]
+	}
+	return total
+}
+
+// Tries every assignment of the rows from the given one on,
+// each row taking a free column or none.
+func bestTotalWeight(weights [][]float64, row int, taken []bool) float64 {
+	if row == len(weights) {
+		return 0
+	}
+	best := bestTotalWeight(weights, row+1, taken)
+	for j := range taken {
+		if taken[j] {
+			continue
+		}
+		taken[j] = true
+		best = math.Max(best, weights[row][j]+bestTotalWeight(weights, row+1, taken))
+		taken[j] = false
+	}
+	return best
+}
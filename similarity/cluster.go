@@ -0,0 +1,284 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+type ClusterMember struct {
+	String    string `json:"string"`
+	Count     int    `json:"count"`
+	Cluster   int    `json:"cluster"`
+	Canonical string `json:"canonical"`
+}
+
+// Flow for grouping near-duplicates of a single list into clusters.
+// Repeated strings are counted and clustered only once. Outputs
+// each distinct string, in input order, with the id of its cluster
+// and the canonical representative chosen for that cluster. The
+// strings are compared after the normalizer, if any, but output
+// as they were given.
+func ClusterFlow(strs []string, metric string, threshold float64, linkage string, canonical string, amountGoroutines int, normalizer *utils.Normalizer, StringFlags map[string]string, BoolFlags map[string]bool) {
+	unique, counts := uniqueWithCounts(strs)
+	normalized := normalizer.NormalizeSlice(unique)
+
+	// Clusters are found on the "closeness" of the strings, which
+	// is the score itself or the negative distance for distance
+	// metrics, so a higher value always means more similar.
+	closeness := func(score float64) float64 {
+		if IsDistanceMetric(metric) {
+			return -score
+		}
+		return score
+	}
+
+	var labels []int
+	switch linkage {
+	case "single":
+		// Single linkage with a threshold is the same as the connected
+		// components of the pairs within the threshold, which only
+		// needs the pairs instead of the full matrix.
+		index := make(map[string]int, len(unique))
+		for i, s := range unique {
+			index[s] = i
+		}
+		uf := newUnionFind(len(unique))
+		for _, pair := range findPairs(unique, normalized, metric, threshold, amountGoroutines) {
+			uf.union(index[pair.S1], index[pair.S2])
+		}
+		labels = make([]int, len(unique))
+		for i := range unique {
+			labels[i] = uf.find(i)
+		}
+	case "average", "complete":
+		matrix := calculateMatrix(normalized, normalized, metric, amountGoroutines)
+		for i := range matrix {
+			for j := range matrix[i] {
+				matrix[i][j] = closeness(matrix[i][j])
+			}
+		}
+		labels = hierarchicalClustering(matrix, linkage, closeness(threshold))
+	default:
+		fmt.Println("Linkage not supported")
+		os.Exit(1)
+	}
+
+	// Number the clusters in the order they first appear
+	clusters := make(map[int][]int)
+	var order []int
+	for i, label := range labels {
+		if _, ok := clusters[label]; !ok {
+			order = append(order, label)
+		}
+		clusters[label] = append(clusters[label], i)
+	}
+
+	calculateSimilarity := getSimilarityFunc(metric)
+
+	members := make([]ClusterMember, len(unique))
+	for id, label := range order {
+		indexes := clusters[label]
+
+		var representative int
+		switch canonical {
+		case "frequent":
+			representative = mostFrequent(indexes, counts)
+		case "central":
+			representative = mostCentral(indexes, normalized, counts, func(s1 string, s2 string) float64 {
+				return closeness(calculateSimilarity(s1, s2))
+			})
+		default:
+			fmt.Println("Canonical representative not supported")
+			os.Exit(1)
+		}
+
+		for _, i := range indexes {
+			members[i] = ClusterMember{
+				String:    unique[i],
+				Count:     counts[i],
+				Cluster:   id,
+				Canonical: unique[representative],
+			}
+		}
+	}
+
+	header := []string{"string", "count", "cluster", "canonical"}
+	rows := make([][]string, len(members))
+	for i, m := range members {
+		rows[i] = []string{m.String, strconv.Itoa(m.Count), strconv.Itoa(m.Cluster), m.Canonical}
+	}
+
+	// Now check if its not set to silent to print results
+	if !BoolFlags["Silent"] {
+		printTable(header, rows)
+	}
+
+	// Check if output to write to file
+	if StringFlags["Output"] != "" {
+		writeTableToFile(StringFlags["Output"], members, header, rows)
+	}
+}
+
+// Returns the distinct strings in the order they first
+// appear, together with how many times each appeared.
+func uniqueWithCounts(strs []string) ([]string, []int) {
+	var unique []string
+	var counts []int
+	index := make(map[string]int)
+	for _, s := range strs {
+		if i, ok := index[s]; ok {
+			counts[i]++
+			continue
+		}
+		index[s] = len(unique)
+		unique = append(unique, s)
+		counts = append(counts, 1)
+	}
+	return unique, counts
+}
+
+// Agglomerative clustering over a matrix of closeness between every
+// pair, always merging the two closest clusters until none are within
+// the threshold. The closeness between clusters is the mean over their
+// members for "average" linkage and the minimum for "complete" linkage.
+// Returns a label for each row of the matrix. Takes O(n^3) time.
+func hierarchicalClustering(matrix [][]float64, linkage string, threshold float64) []int {
+	n := len(matrix)
+
+	// Closeness between the current clusters, updated in place
+	// as clusters get merged, and the size of each cluster.
+	link := make([][]float64, n)
+	for i := range matrix {
+		link[i] = append([]float64(nil), matrix[i]...)
+	}
+	sizes := make([]int, n)
+	active := make([]bool, n)
+	labels := make([]int, n)
+	for i := range labels {
+		sizes[i] = 1
+		active[i] = true
+		labels[i] = i
+	}
+
+	for {
+		// Find the closest pair of active clusters
+		a, b := -1, -1
+		best := math.Inf(-1)
+		for i := 0; i < n; i++ {
+			if !active[i] {
+				continue
+			}
+			for j := i + 1; j < n; j++ {
+				if active[j] && link[i][j] > best {
+					a, b, best = i, j, link[i][j]
+				}
+			}
+		}
+
+		// Stop when no pair is left within the threshold
+		if a == -1 || best < threshold {
+			break
+		}
+
+		// Merge b into a, updating the closeness of a
+		// to every other cluster with the linkage
+		for k := 0; k < n; k++ {
+			if !active[k] || k == a || k == b {
+				continue
+			}
+			var merged float64
+			if linkage == "complete" {
+				merged = math.Min(link[a][k], link[b][k])
+			} else {
+				merged = (float64(sizes[a])*link[a][k] + float64(sizes[b])*link[b][k]) / float64(sizes[a]+sizes[b])
+			}
+			link[a][k] = merged
+			link[k][a] = merged
+		}
+		sizes[a] += sizes[b]
+		active[b] = false
+		for i := range labels {
+			if labels[i] == b {
+				labels[i] = a
+			}
+		}
+	}
+
+	return labels
+}
+
+// The member that appeared the most times in the input,
+// the first one to appear in case of a tie.
+func mostFrequent(indexes []int, counts []int) int {
+	best := indexes[0]
+	for _, i := range indexes[1:] {
+		if counts[i] > counts[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// The member with the highest total closeness to every other
+// occurrence in the cluster, including the repeats of itself.
+func mostCentral(indexes []int, strs []string, counts []int, closeness func(string, string) float64) int {
+	best := indexes[0]
+	bestTotal := math.Inf(-1)
+	for _, i := range indexes {
+		total := 0.0
+		for _, j := range indexes {
+			weight := counts[j]
+			if i == j {
+				weight--
+			}
+			total += float64(weight) * closeness(strs[i], strs[j])
+		}
+		if total > bestTotal {
+			best, bestTotal = i, total
+		}
+	}
+	return best
+}
+
+// Disjoint sets with path halving and union by rank,
+// used to find the connected components of the pairs.
+type unionFind struct {
+	parent []int
+	rank   []int
+}
+
+func newUnionFind(n int) *unionFind {
+	u := &unionFind{parent: make([]int, n), rank: make([]int, n)}
+	for i := range u.parent {
+		u.parent[i] = i
+	}
+	return u
+}
+
+// Returns the root of the set containing x.
+func (u *unionFind) find(x int) int {
+	for u.parent[x] != x {
+		u.parent[x] = u.parent[u.parent[x]]
+		x = u.parent[x]
+	}
+	return x
+}
+
+// Joins the sets containing a and b.
+func (u *unionFind) union(a, b int) {
+	ra, rb := u.find(a), u.find(b)
+	if ra == rb {
+		return
+	}
+	if u.rank[ra] < u.rank[rb] {
+		ra, rb = rb, ra
+	}
+	u.parent[rb] = ra
+	if u.rank[ra] == u.rank[rb] {
+		u.rank[ra]++
+	}
+}
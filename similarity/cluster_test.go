@@ -0,0 +1,119 @@
+package similarity
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestUnionFind(t *testing.T) {
+	cases := []struct {
+		n     int
+		pairs [][2]int
+		want  []int
+	}{
+		{4, nil, []int{0, 1, 2, 3}},
+		{4, [][2]int{{0, 1}, {2, 3}}, []int{0, 0, 1, 1}},
+		{5, [][2]int{{0, 4}, {4, 2}, {2, 0}}, []int{0, 1, 0, 2, 0}},
+		{5, [][2]int{{0, 1}, {2, 3}, {1, 3}, {4, 4}}, []int{0, 0, 0, 0, 1}},
+	}
+
+	for _, c := range cases {
+		uf := newUnionFind(c.n)
+		for _, pair := range c.pairs {
+			uf.union(pair[0], pair[1])
+		}
+		labels := make([]int, c.n)
+		for i := range labels {
+			labels[i] = uf.find(i)
+		}
+		if got := relabel(labels); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("union find of %v over %d = %v, want %v", c.pairs, c.n, got, c.want)
+		}
+	}
+}
+
+// Points on a line at 0, 1, 3 and 10, with the negative distance as
+// the closeness. Average linkage joins 3 to {0, 1} at a mean distance
+// of 2.5, while complete linkage takes the farthest one, 3.
+func TestHierarchicalClustering(t *testing.T) {
+	points := []float64{0, 1, 3, 10}
+	matrix := make([][]float64, len(points))
+	for i := range points {
+		matrix[i] = make([]float64, len(points))
+		for j := range points {
+			matrix[i][j] = -math.Abs(points[i] - points[j])
+		}
+	}
+
+	cases := []struct {
+		linkage   string
+		threshold float64
+		want      []int
+	}{
+		{"average", -2.5, []int{0, 0, 0, 1}},
+		{"complete", -2.5, []int{0, 0, 1, 2}},
+		{"complete", -3, []int{0, 0, 0, 1}},
+		{"average", -0.5, []int{0, 1, 2, 3}},
+		{"average", math.Inf(-1), []int{0, 0, 0, 0}},
+	}
+
+	for _, c := range cases {
+		got := relabel(hierarchicalClustering(matrix, c.linkage, c.threshold))
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s linkage with threshold %v = %v, want %v", c.linkage, c.threshold, got, c.want)
+		}
+	}
+}
+
+func TestUniqueWithCounts(t *testing.T) {
+	unique, counts := uniqueWithCounts([]string{"b", "a", "b", "c", "b", "a"})
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(unique, want) {
+		t.Errorf("unique = %q, want %q", unique, want)
+	}
+	if want := []int{3, 2, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	strs := []string{"jon", "john", "johnn", "jonathan"}
+	closeness := func(s1 string, s2 string) float64 {
+		return getSimilarityFunc("jaro")(s1, s2)
+	}
+
+	cases := []struct {
+		indexes  []int
+		counts   []int
+		frequent int
+		central  int
+	}{
+		{[]int{0, 1, 2}, []int{1, 1, 1}, 0, 1},
+		{[]int{0, 1, 2}, []int{1, 1, 5}, 2, 2},
+		{[]int{1, 3}, []int{1, 2, 1, 2}, 1, 1},
+		{[]int{3}, []int{1, 1, 1, 1}, 3, 3},
+	}
+
+	for _, c := range cases {
+		if got := mostFrequent(c.indexes, c.counts); got != c.frequent {
+			t.Errorf("mostFrequent(%v, %v) = %d, want %d", c.indexes, c.counts, got, c.frequent)
+		}
+		if got := mostCentral(c.indexes, strs, c.counts, closeness); got != c.central {
+			t.Errorf("mostCentral(%v, %v) = %d, want %d", c.indexes, c.counts, got, c.central)
+		}
+	}
+}
+
+// Numbers the labels in the order they first appear,
+// so equal partitions get equal labels.
+func relabel(labels []int) []int {
+	ids := make(map[int]int)
+	relabeled := make([]int, len(labels))
+	for i, label := range labels {
+		if _, ok := ids[label]; !ok {
+			ids[label] = len(ids)
+		}
+		relabeled[i] = ids[label]
+	}
+	return relabeled
+}
@@ -0,0 +1,91 @@
+package similarity
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// Flow for finding near-duplicates inside a single list. Each pair
+// is only compared once, s[i] against s[j] for i < j, skipping
+// the self-pairs. Only the pairs within the threshold are kept,
+// sorted by score and printed or written all at once.
+func DedupeFlow(strs []string, metric string, threshold float64, amountGoroutines int, normalizer *utils.Normalizer, StringFlags map[string]string, BoolFlags map[string]bool) {
+	similarities := findPairs(strs, normalizer.NormalizeSlice(strs), metric, threshold, amountGoroutines)
+
+	// Sort the slice by score
+	sort.Slice(similarities, func(i, j int) bool {
+		return similarities[i].Score > similarities[j].Score
+	})
+
+	// Now check if its not set to silent to print results
+	if !BoolFlags["Silent"] {
+		printResults(similarities)
+	}
+
+	// Check if output to write to file
+	if StringFlags["Output"] != "" {
+		// Write to file
+		writeToFile(StringFlags["Output"], similarities)
+	}
+}
+
+// Compares every pair s[i], s[j] with i < j concurrently and
+// returns the ones within the threshold, in no particular order.
+// The normalized strings, given in the same order, are compared,
+// while the originals are kept for the output.
+func findPairs(strs []string, normalized []string, metric string, threshold float64, amountGoroutines int) []Similarity {
+	calculateSimilarity := getSimilarityFunc(metric)
+	checkPairs(metric, normalized, normalized)
+
+	var similarities []Similarity
+	var skipped skippedPairs
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Add the amount of goroutines to the wait group
+	wg.Add(amountGoroutines)
+
+	// Each goroutine takes every 'amountGoroutines'th row. Since
+	// the first rows have the most pairs, taking them in turns
+	// spreads the work more evenly than contiguous blocks.
+	for g := 0; g < amountGoroutines; g++ {
+		go func(first int) {
+			for i := first; i < len(strs); i += amountGoroutines {
+				for j := i + 1; j < len(strs); j++ {
+					if skipsPair(metric, normalized[i], normalized[j]) {
+						skipped.add(strs[i], strs[j])
+						continue
+					}
+					// Calculate the similarity
+					score := calculateSimilarity(normalized[i], normalized[j])
+					if !passesThreshold(metric, score, threshold) {
+						continue
+					}
+					// Create a new similarity object
+					similarity := Similarity{
+						Metric:       getPrettyMetricName(metric),
+						S1:           strs[i],
+						S2:           strs[j],
+						Score:        score,
+						S1Normalized: normalized[i],
+						S2Normalized: normalized[j],
+					}
+					// Add the similarity to the slice
+					mu.Lock()
+					similarities = append(similarities, similarity)
+					mu.Unlock()
+				}
+			}
+			// Done with this goroutine
+			wg.Done()
+		}(g)
+	}
+
+	// Wait for all goroutines to finish
+	wg.Wait()
+	skipped.report(metric)
+
+	return similarities
+}
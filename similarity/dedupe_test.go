@@ -0,0 +1,53 @@
+package similarity
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Every pair is compared once, never a string against itself, and
+// the normalized strings are compared while the originals are kept.
+func TestFindPairs(t *testing.T) {
+	cases := []struct {
+		strs       []string
+		normalized []string
+		metric     string
+		threshold  float64
+		want       []string
+	}{
+		{[]string{"adam", "adan", "eve"}, []string{"adam", "adan", "eve"}, "levenshtein", 1, []string{"adam|adan"}},
+		{[]string{"adam", "adan", "eve"}, []string{"adam", "adan", "eve"}, "levenshtein", 4, []string{"adam|adan", "adam|eve", "adan|eve"}},
+		{[]string{"adam", "adan", "eve"}, []string{"adam", "adan", "eve"}, "jaro", 0.9, nil},
+		{[]string{"Adam", "ADAM", "adan"}, []string{"adam", "adam", "adan"}, "jaro", 1, []string{"Adam|ADAM"}},
+		{[]string{"adam"}, []string{"adam"}, "jaro", 0, nil},
+		{nil, nil, "jaro", 0, nil},
+	}
+
+	for _, c := range cases {
+		for _, goroutines := range []int{1, 2} {
+			var got []string
+			for _, pair := range findPairs(c.strs, c.normalized, c.metric, c.threshold, goroutines) {
+				got = append(got, pair.S1+"|"+pair.S2)
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("findPairs(%q, %s, %v) with %d goroutines = %q, want %q", c.strs, c.metric, c.threshold, goroutines, got, c.want)
+			}
+		}
+	}
+}
+
+func TestFindPairsNormalized(t *testing.T) {
+	pairs := findPairs([]string{"São Paulo", "SAO PAULO"}, []string{"sao paulo", "sao paulo"}, "jaro", 1, 1)
+	if len(pairs) != 1 {
+		t.Fatalf("findPairs found %d pairs, want 1", len(pairs))
+	}
+	pair := pairs[0]
+	if pair.S1 != "São Paulo" || pair.S2 != "SAO PAULO" || pair.S1Normalized != "sao paulo" || pair.S2Normalized != "sao paulo" || pair.Score != 1 {
+		t.Errorf("findPairs = %+v, want the original and normalized strings with a score of 1", pair)
+	}
+	if pair.Metric != "Jaro" {
+		t.Errorf("findPairs metric = %q, want Jaro", pair.Metric)
+	}
+}
@@ -0,0 +1,227 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// A pair of strings labeled as being a match or not.
+type LabeledPair struct {
+	S1      string
+	S2      string
+	IsMatch bool
+}
+
+// Applies the normalizer to both strings of every pair,
+// returning new pairs with the same labels.
+func normalizePairs(pairs []LabeledPair, normalizer *utils.Normalizer) []LabeledPair {
+	normalized := make([]LabeledPair, len(pairs))
+	for i, pair := range pairs {
+		normalized[i] = LabeledPair{S1: normalizer.Normalize(pair.S1), S2: normalizer.Normalize(pair.S2), IsMatch: pair.IsMatch}
+	}
+	return normalized
+}
+
+// Precision, recall and F1 when every pair with a score of at
+// least the threshold, or at most for distances, is a match.
+type ThresholdEvaluation struct {
+	Metric    string  `json:"metric"`
+	Threshold float64 `json:"threshold"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+	// Confusion matrix at the threshold
+	TruePositives  int `json:"true_positives"`
+	FalsePositives int `json:"false_positives"`
+	FalseNegatives int `json:"false_negatives"`
+	TrueNegatives  int `json:"true_negatives"`
+}
+
+type MetricEvaluation struct {
+	Metric string  `json:"metric"`
+	RocAuc float64 `json:"roc_auc"`
+	PrAuc  float64 `json:"pr_auc"`
+	// Threshold with the highest F1 and its precision and recall
+	Best  ThresholdEvaluation   `json:"best"`
+	Curve []ThresholdEvaluation `json:"curve"`
+}
+
+// Flow for evaluating metrics against pairs labeled as matches or not.
+// Every distinct score is tried as a threshold, reporting precision,
+// recall and F1 at each one, the areas under the ROC and the
+// precision-recall curves and the threshold with the best F1. The
+// pairs are compared after the normalizer, if any.
+func EvaluateFlow(pairs []LabeledPair, metrics []string, normalizer *utils.Normalizer, StringFlags map[string]string, BoolFlags map[string]bool) {
+	pairs = normalizePairs(pairs, normalizer)
+
+	var evaluations []MetricEvaluation
+	var skipped []string
+	for _, metric := range metrics {
+		// A metric that would stop on a pair is left out,
+		// keeping the evaluations of the others
+		if failsOnAnyPair(metric, pairs) {
+			skipped = append(skipped, getPrettyMetricName(metric))
+			continue
+		}
+		evaluations = append(evaluations, evaluateMetric(pairs, metric))
+	}
+
+	// Best metrics first
+	sort.SliceStable(evaluations, func(i, j int) bool {
+		return evaluations[i].Best.F1 > evaluations[j].Best.F1
+	})
+
+	header := []string{"metric", "roc_auc", "pr_auc", "best_threshold", "precision", "recall", "f1"}
+	rows := make([][]string, len(evaluations))
+	for i, e := range evaluations {
+		rows[i] = []string{
+			e.Metric,
+			fmt.Sprintf("%f", e.RocAuc),
+			fmt.Sprintf("%f", e.PrAuc),
+			fmt.Sprintf("%f", e.Best.Threshold),
+			fmt.Sprintf("%f", e.Best.Precision),
+			fmt.Sprintf("%f", e.Best.Recall),
+			fmt.Sprintf("%f", e.Best.F1),
+		}
+	}
+
+	// Now check if its not set to silent to print results
+	if !BoolFlags["Silent"] {
+		printTable(header, rows)
+	}
+	if !BoolFlags["Silent"] && len(skipped) > 0 {
+		fmt.Printf("\nSkipped, since they can't score every pair: %s\n", strings.Join(skipped, ", "))
+	}
+
+	// Check if output to write to file. A json holds everything,
+	// while a csv holds the summary and the precision, recall and
+	// F1 at every threshold go to a second csv file.
+	if StringFlags["Output"] != "" {
+		if utils.FileExt(StringFlags["Output"]) == ".json" {
+			writeTableToFile(StringFlags["Output"], evaluations, nil, nil)
+		} else {
+			writeTableToFile(StringFlags["Output"], nil, header, rows)
+
+			var curveRows [][]string
+			for _, e := range evaluations {
+				for _, point := range e.Curve {
+					curveRows = append(curveRows, []string{
+						point.Metric,
+						fmt.Sprintf("%f", point.Threshold),
+						fmt.Sprintf("%f", point.Precision),
+						fmt.Sprintf("%f", point.Recall),
+						fmt.Sprintf("%f", point.F1),
+					})
+				}
+			}
+			curveHeader := []string{"metric", "threshold", "precision", "recall", "f1"}
+			writeTableToFile(utils.AddFileSuffix(StringFlags["Output"], "_curve"), nil, curveHeader, curveRows)
+		}
+	}
+}
+
+// Scores every pair with the metric and evaluates it. Pairs
+// the metric skips are left out of the evaluation.
+func evaluateMetric(pairs []LabeledPair, metric string) MetricEvaluation {
+	calculateSimilarity := getSimilarityFunc(metric)
+	var skipped skippedPairs
+	var scored []LabeledPair
+	var scores []float64
+	for _, pair := range pairs {
+		if skipsPair(metric, pair.S1, pair.S2) {
+			skipped.add(pair.S1, pair.S2)
+			continue
+		}
+		scored = append(scored, pair)
+		scores = append(scores, calculateSimilarity(pair.S1, pair.S2))
+	}
+	skipped.report(metric)
+	return evaluateScores(scored, scores, metric)
+}
+
+// Sweeps the scores from the most to the least similar, each distinct
+// score being a threshold, accumulating the true and false positives.
+func evaluateScores(pairs []LabeledPair, scores []float64, metric string) MetricEvaluation {
+	evaluation := MetricEvaluation{Metric: getPrettyMetricName(metric)}
+
+	// Closeness of each pair, so higher is always more similar.
+	// Scores that aren't a number, which only ratios between two
+	// empty strings give, are taken as the least similar ratio.
+	order := make([]int, len(pairs))
+	closeness := make([]float64, len(pairs))
+	scores = append([]float64(nil), scores...)
+	positives, negatives := 0, 0
+	for i := range pairs {
+		order[i] = i
+		if math.IsNaN(scores[i]) {
+			scores[i] = 0
+		}
+		closeness[i] = scores[i]
+		if IsDistanceMetric(metric) {
+			closeness[i] = -scores[i]
+		}
+		if pairs[i].IsMatch {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return closeness[order[a]] > closeness[order[b]]
+	})
+
+	tp, fp := 0, 0
+	prevTpr, prevFpr, prevRecall := 0.0, 0.0, 0.0
+	for k := 0; k < len(order); {
+		// Take every pair tied with the same score at once
+		threshold := scores[order[k]]
+		for k < len(order) && scores[order[k]] == threshold {
+			if pairs[order[k]].IsMatch {
+				tp++
+			} else {
+				fp++
+			}
+			k++
+		}
+
+		precision := float64(tp) / float64(tp+fp)
+		recall := safeDivide(float64(tp), float64(positives))
+		fpr := safeDivide(float64(fp), float64(negatives))
+		point := ThresholdEvaluation{
+			Metric:    evaluation.Metric,
+			Threshold: threshold,
+			Precision: precision,
+			Recall:    recall,
+			F1:        safeDivide(2*precision*recall, precision+recall),
+
+			TruePositives:  tp,
+			FalsePositives: fp,
+			FalseNegatives: positives - tp,
+			TrueNegatives:  negatives - fp,
+		}
+		evaluation.Curve = append(evaluation.Curve, point)
+		if point.F1 > evaluation.Best.F1 || len(evaluation.Curve) == 1 {
+			evaluation.Best = point
+		}
+
+		// Trapezoids under the ROC curve, and the average precision
+		// as the area under the precision-recall curve
+		evaluation.RocAuc += (fpr - prevFpr) * (recall + prevTpr) / 2
+		evaluation.PrAuc += (recall - prevRecall) * precision
+		prevTpr, prevFpr, prevRecall = recall, fpr, recall
+	}
+
+	return evaluation
+}
+
+// Division that returns 0 instead of NaN when dividing by 0.
+func safeDivide(a float64, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
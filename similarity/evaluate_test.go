@@ -0,0 +1,74 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+func TestEvaluateScores(t *testing.T) {
+	cases := []struct {
+		name      string
+		metric    string
+		matches   []bool
+		scores    []float64
+		rocAuc    float64
+		prAuc     float64
+		threshold float64
+		f1        float64
+	}{
+		{"separated", "jaro", []bool{true, true, false, false}, []float64{0.9, 0.8, 0.3, 0.1}, 1, 1, 0.8, 1},
+		{"reversed", "jaro", []bool{false, false, true, true}, []float64{0.9, 0.8, 0.3, 0.1}, 0, 5.0 / 12, 0.1, 2.0 / 3},
+		{"tied", "jaro", []bool{true, false}, []float64{0.5, 0.5}, 0.5, 0.5, 0.5, 2.0 / 3},
+		{"one mistake", "jaro", []bool{true, false, true, false}, []float64{0.9, 0.8, 0.7, 0.1}, 0.75, 5.0 / 6, 0.7, 0.8},
+		// Lower distances are more similar
+		{"distance", "levenshtein", []bool{true, true, false}, []float64{0, 1, 3}, 1, 1, 1, 1},
+		// NaN, from a ratio of two empty strings, is the least similar
+		{"nan", "levenshteinratio", []bool{true, false}, []float64{0.5, math.NaN()}, 1, 1, 0.5, 1},
+		{"no matches", "jaro", []bool{false, false}, []float64{0.9, 0.1}, 0, 0, 0.9, 0},
+	}
+
+	for _, c := range cases {
+		pairs := make([]LabeledPair, len(c.matches))
+		for i, isMatch := range c.matches {
+			pairs[i] = LabeledPair{IsMatch: isMatch}
+		}
+		got := evaluateScores(pairs, c.scores, c.metric)
+		if math.Abs(got.RocAuc-c.rocAuc) > 1e-12 || math.Abs(got.PrAuc-c.prAuc) > 1e-12 {
+			t.Errorf("%s: ROC AUC %v and PR AUC %v, want %v and %v", c.name, got.RocAuc, got.PrAuc, c.rocAuc, c.prAuc)
+		}
+		if got.Best.Threshold != c.threshold || math.Abs(got.Best.F1-c.f1) > 1e-12 {
+			t.Errorf("%s: best threshold %v with F1 %v, want %v with %v", c.name, got.Best.Threshold, got.Best.F1, c.threshold, c.f1)
+		}
+	}
+}
+
+// The confusion matrix at each threshold adds up to every pair.
+func TestEvaluateScoresCurve(t *testing.T) {
+	pairs := []LabeledPair{{IsMatch: true}, {IsMatch: false}, {IsMatch: true}, {IsMatch: false}}
+	evaluation := evaluateScores(pairs, []float64{0.9, 0.8, 0.7, 0.1}, "jaro")
+
+	want := [][4]int{{1, 0, 1, 2}, {1, 1, 1, 1}, {2, 1, 0, 1}, {2, 2, 0, 0}}
+	if len(evaluation.Curve) != len(want) {
+		t.Fatalf("curve has %d thresholds, want %d", len(evaluation.Curve), len(want))
+	}
+	for i, point := range evaluation.Curve {
+		got := [4]int{point.TruePositives, point.FalsePositives, point.FalseNegatives, point.TrueNegatives}
+		if got != want[i] {
+			t.Errorf("confusion matrix at %v = %v, want %v", point.Threshold, got, want[i])
+		}
+	}
+}
+
+func TestNormalizePairs(t *testing.T) {
+	pairs := []LabeledPair{{S1: "São Paulo", S2: "SAO PAULO", IsMatch: true}}
+
+	got := normalizePairs(pairs, utils.NewNormalizer([]string{"lowercase", "unidecode"}))
+	if want := (LabeledPair{S1: "sao paulo", S2: "sao paulo", IsMatch: true}); got[0] != want {
+		t.Errorf("normalizePairs = %+v, want %+v", got[0], want)
+	}
+	if got := normalizePairs(pairs, nil); got[0] != pairs[0] {
+		t.Errorf("normalizePairs without a normalizer = %+v, want %+v", got[0], pairs[0])
+	}
+}
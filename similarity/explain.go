@@ -0,0 +1,550 @@
+package similarity
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// Why two strings got their score. Edit distance metrics get the
+// edit operations turning s1 into s2, while Jaro gets the matched
+// characters and the amount of transpositions. Both get a two line
+// alignment of the strings.
+type Explanation struct {
+	Operations     []EditOperation  `json:"operations,omitempty"`
+	Matches        []CharacterMatch `json:"matches,omitempty"`
+	Transpositions int              `json:"transpositions,omitempty"`
+	Alignment      []string         `json:"alignment,omitempty"`
+	// Synonyms replaced while normalizing each string
+	Synonyms1 []utils.Substitution `json:"s1_synonyms,omitempty"`
+	Synonyms2 []utils.Substitution `json:"s2_synonyms,omitempty"`
+}
+
+// A single edit, with the index of the first character it touches
+// in each string. Type is one of insert, delete, substitute and
+// transpose, where a transpose swaps two characters.
+type EditOperation struct {
+	Type      string `json:"type"`
+	Position1 int    `json:"position1"`
+	Position2 int    `json:"position2"`
+	S1        string `json:"s1,omitempty"`
+	S2        string `json:"s2,omitempty"`
+}
+
+// A character of s1 matched to the same character of s2 by Jaro.
+type CharacterMatch struct {
+	Character string `json:"character"`
+	Position1 int    `json:"position1"`
+	Position2 int    `json:"position2"`
+}
+
+// Gap shown in the alignment where a string has no character.
+const alignmentGap = '-'
+
+// Explains the score of the metric for the pair, or returns nil
+// for metrics that can't be explained.
+func explainSimilarity(metric string, s1 string, s2 string) *Explanation {
+	switch metric {
+	case "levenshtein", "levenshteinratio":
+		return explainLevenshtein([]rune(s1), []rune(s2))
+	case "dameraulevenshtein":
+		return explainDamerauLevenshtein([]rune(s1), []rune(s2))
+	case "weightedlevenshtein":
+		return explainWeightedLevenshtein([]rune(s1), []rune(s2))
+	case "weighteddameraulevenshtein":
+		return explainWeightedDamerauLevenshtein([]rune(s1), []rune(s2))
+	case "hamming":
+		return explainHamming([]rune(s1), []rune(s2))
+	case "jaro":
+		return explainJaro([]rune(s1), []rune(s2))
+	default:
+		return nil
+	}
+}
+
+// Normalizes every string and, when explaining, keeps the synonyms
+// replaced in each one, so they aren't searched again for every pair.
+func normalizeExplained(strs []string, normalizer *utils.Normalizer, explain bool) ([]string, [][]utils.Substitution) {
+	if !explain {
+		return normalizer.NormalizeSlice(strs), nil
+	}
+	normalized := make([]string, len(strs))
+	synonyms := make([][]utils.Substitution, len(strs))
+	for i, s := range strs {
+		normalized[i], synonyms[i] = normalizer.NormalizeWithSubstitutions(s)
+	}
+	return normalized, synonyms
+}
+
+// Explains the score of a pair of normalized strings, adding the
+// synonyms replaced while normalizing each of them. Metrics that
+// can't be explained still get the synonyms, if any.
+func explainNormalized(metric string, s1 string, s2 string, synonyms1 []utils.Substitution, synonyms2 []utils.Substitution) *Explanation {
+	explanation := explainSimilarity(metric, s1, s2)
+	if len(synonyms1) == 0 && len(synonyms2) == 0 {
+		return explanation
+	}
+	if explanation == nil {
+		explanation = &Explanation{}
+	}
+	explanation.Synonyms1 = synonyms1
+	explanation.Synonyms2 = synonyms2
+	return explanation
+}
+
+// Builds the explanation from the alignment of the two strings, one
+// column per character or gap, taking every column that isn't an
+// equal character as an operation. Transpositions are marked by
+// the columns starting and ending them.
+type alignmentBuilder struct {
+	top        []rune
+	bottom     []rune
+	operations []EditOperation
+}
+
+func (a *alignmentBuilder) column(op string, i int, j int, c1 rune, c2 rune) {
+	a.top = append(a.top, c1)
+	a.bottom = append(a.bottom, c2)
+	switch op {
+	case "insert":
+		a.operations = append(a.operations, EditOperation{Type: op, Position1: i, Position2: j, S2: string(c2)})
+	case "delete":
+		a.operations = append(a.operations, EditOperation{Type: op, Position1: i, Position2: j, S1: string(c1)})
+	case "substitute":
+		a.operations = append(a.operations, EditOperation{Type: op, Position1: i, Position2: j, S1: string(c1), S2: string(c2)})
+	}
+}
+
+// Substitution of a whole sequence of s1, starting at i, by one of s2,
+// starting at j, with gaps after the shorter one in the alignment.
+func (a *alignmentBuilder) sequence(i int, j int, from []rune, to []rune) {
+	for k := utils.Max(len(from), len(to)) - 1; k >= 0; k-- {
+		c1, c2 := rune(alignmentGap), rune(alignmentGap)
+		if k < len(from) {
+			c1 = from[k]
+		}
+		if k < len(to) {
+			c2 = to[k]
+		}
+		a.top = append(a.top, c1)
+		a.bottom = append(a.bottom, c2)
+	}
+	a.operations = append(a.operations, EditOperation{Type: "substitute", Position1: i, Position2: j, S1: string(from), S2: string(to)})
+}
+
+// Transposition of r1[i1-1] and r1[i-1], which are r2[j-1] and
+// r2[j1-1], with everything in between deleted from s1 or
+// inserted from s2. Positions count from 1, as in the matrices.
+func (a *alignmentBuilder) transposition(r1 []rune, r2 []rune, i int, j int, i1 int, j1 int) {
+	a.column("match", i-1, j-1, r1[i-1], r2[j-1])
+	for k := j - 1; k > j1; k-- {
+		a.column("insert", i-1, k-1, alignmentGap, r2[k-1])
+	}
+	for k := i - 1; k > i1; k-- {
+		a.column("delete", k-1, j1, r1[k-1], alignmentGap)
+	}
+	a.column("match", i1-1, j1-1, r1[i1-1], r2[j1-1])
+	a.operations = append(a.operations, EditOperation{
+		Type:      "transpose",
+		Position1: i1 - 1,
+		Position2: j1 - 1,
+		S1:        string([]rune{r1[i1-1], r1[i-1]}),
+		S2:        string([]rune{r2[j1-1], r2[j-1]}),
+	})
+}
+
+// Operations were added walking backwards, so reverse everything.
+func (a *alignmentBuilder) explanation() *Explanation {
+	reverseRunes(a.top)
+	reverseRunes(a.bottom)
+	for l, r := 0, len(a.operations)-1; l < r; l, r = l+1, r-1 {
+		a.operations[l], a.operations[r] = a.operations[r], a.operations[l]
+	}
+	return &Explanation{
+		Operations: a.operations,
+		Alignment:  []string{string(a.top), string(a.bottom)},
+	}
+}
+
+// Backtracks the Levenshtein matrix into the edit operations,
+// preferring matches and substitutions, then deletions.
+func explainLevenshtein(r1 []rune, r2 []rune) *Explanation {
+	d := make([][]int, len(r1)+1)
+	for i := range d {
+		d[i] = make([]int, len(r2)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(r1); i++ {
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			d[i][j] = utils.Min(d[i-1][j-1]+cost, utils.Min(d[i-1][j]+1, d[i][j-1]+1))
+		}
+	}
+
+	var a alignmentBuilder
+	i, j := len(r1), len(r2)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && r1[i-1] == r2[j-1] && d[i][j] == d[i-1][j-1]:
+			a.column("match", i-1, j-1, r1[i-1], r2[j-1])
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && d[i][j] == d[i-1][j-1]+1:
+			a.column("substitute", i-1, j-1, r1[i-1], r2[j-1])
+			i, j = i-1, j-1
+		case i > 0 && d[i][j] == d[i-1][j]+1:
+			a.column("delete", i-1, j, r1[i-1], alignmentGap)
+			i--
+		default:
+			a.column("insert", i, j-1, alignmentGap, r2[j-1])
+			j--
+		}
+	}
+	return a.explanation()
+}
+
+// Backtracks the unrestricted Damerau-Levenshtein matrix, the same
+// distance matchr.DamerauLevenshtein computes, into the edit
+// operations. A transposition can have characters deleted or
+// inserted between the two swapped characters.
+func explainDamerauLevenshtein(r1 []rune, r2 []rune) *Explanation {
+	// Lowrance-Wagner algorithm, with the matrix shifted by one
+	// so row and column 0 hold the maximum possible distance.
+	inf := len(r1) + len(r2)
+	d := make([][]int, len(r1)+2)
+	for i := range d {
+		d[i] = make([]int, len(r2)+2)
+	}
+	d[0][0] = inf
+	for i := 0; i <= len(r1); i++ {
+		d[i+1][0] = inf
+		d[i+1][1] = i
+	}
+	for j := 0; j <= len(r2); j++ {
+		d[0][j+1] = inf
+		d[1][j+1] = j
+	}
+
+	// Last row each character was seen in s1, and for each cell
+	// where its transposition would start, to backtrack it.
+	lastRow := make(map[rune]int)
+	type swap struct{ i1, j1 int }
+	swaps := make([][]swap, len(r1)+1)
+	for i := 1; i <= len(r1); i++ {
+		swaps[i] = make([]swap, len(r2)+1)
+		lastCol := 0
+		for j := 1; j <= len(r2); j++ {
+			i1 := lastRow[r2[j-1]]
+			j1 := lastCol
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+				lastCol = j
+			}
+			d[i+1][j+1] = utils.Min(
+				utils.Min(d[i][j]+cost, d[i+1][j]+1),
+				utils.Min(d[i][j+1]+1, d[i1][j1]+(i-i1-1)+1+(j-j1-1)),
+			)
+			swaps[i][j] = swap{i1, j1}
+		}
+		lastRow[r1[i-1]] = i
+	}
+
+	var a alignmentBuilder
+	i, j := len(r1), len(r2)
+	for i > 0 || j > 0 {
+		switch {
+		case i > 0 && j > 0 && r1[i-1] == r2[j-1] && d[i+1][j+1] == d[i][j]:
+			a.column("match", i-1, j-1, r1[i-1], r2[j-1])
+			i, j = i-1, j-1
+		case i > 0 && j > 0 && d[i+1][j+1] == d[i][j]+1:
+			a.column("substitute", i-1, j-1, r1[i-1], r2[j-1])
+			i, j = i-1, j-1
+		case i > 0 && d[i+1][j+1] == d[i][j+1]+1:
+			a.column("delete", i-1, j, r1[i-1], alignmentGap)
+			i--
+		case j > 0 && d[i+1][j+1] == d[i+1][j]+1:
+			a.column("insert", i, j-1, alignmentGap, r2[j-1])
+			j--
+		default:
+			s := swaps[i][j]
+			a.transposition(r1, r2, i, j, s.i1, s.j1)
+			i, j = s.i1-1, s.j1-1
+		}
+	}
+	return a.explanation()
+}
+
+// Backtracks the weighted Levenshtein matrix into the edit operations,
+// in the same order of preference as Levenshtein, with substitutions
+// of whole sequences from the substitution cost file.
+func explainWeightedLevenshtein(r1 []rune, r2 []rune) *Explanation {
+	d := weightedLevenshteinMatrix(r1, r2)
+	distance := func(i int, j int) float64 {
+		return d[i][j]
+	}
+
+	var a alignmentBuilder
+	i, j := len(r1), len(r2)
+	for i > 0 || j > 0 {
+		if j == 0 {
+			a.column("delete", i-1, j, r1[i-1], alignmentGap)
+			i--
+			continue
+		}
+		if i == 0 {
+			a.column("insert", i, j-1, alignmentGap, r2[j-1])
+			j--
+			continue
+		}
+		rule := sequenceRuleReaching(r1, r2, i, j, d[i][j], distance)
+		switch {
+		case r1[i-1] == r2[j-1] && d[i][j] == d[i-1][j-1]:
+			a.column("match", i-1, j-1, r1[i-1], r2[j-1])
+			i, j = i-1, j-1
+		case d[i][j] == d[i-1][j-1]+substitutionCost(r1[i-1], r2[j-1]):
+			a.column("substitute", i-1, j-1, r1[i-1], r2[j-1])
+			i, j = i-1, j-1
+		case rule != nil:
+			i, j = i-len(rule.from), j-len(rule.to)
+			a.sequence(i, j, rule.from, rule.to)
+		case d[i][j] == d[i-1][j]+editCosts.Delete:
+			a.column("delete", i-1, j, r1[i-1], alignmentGap)
+			i--
+		default:
+			a.column("insert", i, j-1, alignmentGap, r2[j-1])
+			j--
+		}
+	}
+	return a.explanation()
+}
+
+// Backtracks the weighted Damerau-Levenshtein matrix into the edit
+// operations, finding the start of each transposition again from
+// the last positions of the swapped characters.
+func explainWeightedDamerauLevenshtein(r1 []rune, r2 []rune) *Explanation {
+	d := weightedDamerauLevenshteinMatrix(r1, r2)
+	distance := func(i int, j int) float64 {
+		return d[i+1][j+1]
+	}
+
+	var a alignmentBuilder
+	i, j := len(r1), len(r2)
+	for i > 0 || j > 0 {
+		if j == 0 {
+			a.column("delete", i-1, j, r1[i-1], alignmentGap)
+			i--
+			continue
+		}
+		if i == 0 {
+			a.column("insert", i, j-1, alignmentGap, r2[j-1])
+			j--
+			continue
+		}
+		rule := sequenceRuleReaching(r1, r2, i, j, d[i+1][j+1], distance)
+		switch {
+		case r1[i-1] == r2[j-1] && d[i+1][j+1] == d[i][j]:
+			a.column("match", i-1, j-1, r1[i-1], r2[j-1])
+			i, j = i-1, j-1
+		case d[i+1][j+1] == d[i][j]+substitutionCost(r1[i-1], r2[j-1]):
+			a.column("substitute", i-1, j-1, r1[i-1], r2[j-1])
+			i, j = i-1, j-1
+		case rule != nil:
+			i, j = i-len(rule.from), j-len(rule.to)
+			a.sequence(i, j, rule.from, rule.to)
+		case d[i+1][j+1] == d[i][j+1]+editCosts.Delete:
+			a.column("delete", i-1, j, r1[i-1], alignmentGap)
+			i--
+		case d[i+1][j+1] == d[i+1][j]+editCosts.Insert:
+			a.column("insert", i, j-1, alignmentGap, r2[j-1])
+			j--
+		default:
+			// The last row of s1 with the character of s2 at j, and
+			// the last column of s2 with the character of s1 at i
+			i1, j1 := i-1, j-1
+			for i1 > 0 && r1[i1-1] != r2[j-1] {
+				i1--
+			}
+			for j1 > 0 && r2[j1-1] != r1[i-1] {
+				j1--
+			}
+			a.transposition(r1, r2, i, j, i1, j1)
+			i, j = i1-1, j1-1
+		}
+	}
+	return a.explanation()
+}
+
+// Rule of the substitution cost file substituting a sequence ending at
+// the first i characters of s1 and j of s2 that reaches the distance
+// there, or nil if none does.
+func sequenceRuleReaching(r1 []rune, r2 []rune, i int, j int, target float64, distance func(int, int) float64) *substitutionRule {
+	for _, rule := range sequenceRules[r1[i-1]] {
+		if len(rule.from) > i || len(rule.to) > j {
+			continue
+		}
+		if !hasRuneSuffix(r1[:i], rule.from) || !hasRuneSuffix(r2[:j], rule.to) {
+			continue
+		}
+		if distance(i-len(rule.from), j-len(rule.to))+rule.cost == target {
+			return &rule
+		}
+	}
+	return nil
+}
+
+// Hamming only substitutes characters at the same position. The
+// characters past the end of the shorter string are deleted or
+// inserted when the policy counts them, or pads, leaving out spaces.
+// Otherwise strings of different lengths have no explanation.
+func explainHamming(r1 []rune, r2 []rune) *Explanation {
+	if len(r1) != len(r2) && hammingPolicy != "count" && hammingPolicy != "pad" {
+		return nil
+	}
+
+	// Walking backwards, as the builder expects
+	a := &alignmentBuilder{}
+	for k := utils.Max(len(r1), len(r2)) - 1; k >= utils.Min(len(r1), len(r2)); k-- {
+		switch {
+		case k >= len(r2) && hammingPolicy == "pad" && r1[k] == ' ':
+			a.column("match", k, k, r1[k], alignmentGap)
+		case k >= len(r2):
+			a.column("delete", k, len(r2), r1[k], alignmentGap)
+		case hammingPolicy == "pad" && r2[k] == ' ':
+			a.column("match", k, k, alignmentGap, r2[k])
+		default:
+			a.column("insert", len(r1), k, alignmentGap, r2[k])
+		}
+	}
+	for k := utils.Min(len(r1), len(r2)) - 1; k >= 0; k-- {
+		op := "match"
+		if r1[k] != r2[k] {
+			op = "substitute"
+		}
+		a.column(op, k, k, r1[k], r2[k])
+	}
+	return a.explanation()
+}
+
+// Finds the matched characters the same way matchr.Jaro does, each
+// character of s1 matching the first unmatched equal character of s2
+// within the search range, and counts the transpositions as half
+// the matched characters that are out of order. The alignment shows
+// each string with its unmatched characters replaced by a gap.
+func explainJaro(r1 []rune, r2 []rune) *Explanation {
+	searchRange := utils.Max(len(r1), len(r2))/2 - 1
+	if searchRange < 0 {
+		searchRange = 0
+	}
+
+	matched1 := make([]bool, len(r1))
+	matched2 := make([]bool, len(r2))
+	for i := range r1 {
+		low := utils.Max(0, i-searchRange)
+		high := utils.Min(len(r2)-1, i+searchRange)
+		for j := low; j <= high; j++ {
+			if !matched2[j] && r1[i] == r2[j] {
+				matched1[i] = true
+				matched2[j] = true
+				break
+			}
+		}
+	}
+
+	// Matches is never nil for Jaro, even without any,
+	// telling it apart from the edit distances
+	explanation := &Explanation{Matches: []CharacterMatch{}}
+
+	// Pair the matched characters in order of appearance,
+	// where each pair of different characters is half
+	// of a transposition.
+	var positions2 []int
+	for j := range r2 {
+		if matched2[j] {
+			positions2 = append(positions2, j)
+		}
+	}
+	k := 0
+	outOfOrder := 0
+	for i := range r1 {
+		if !matched1[i] {
+			continue
+		}
+		j := positions2[k]
+		k++
+		explanation.Matches = append(explanation.Matches, CharacterMatch{
+			Character: string(r1[i]),
+			Position1: i,
+			Position2: j,
+		})
+		if r1[i] != r2[j] {
+			outOfOrder++
+		}
+	}
+	explanation.Transpositions = outOfOrder / 2
+
+	explanation.Alignment = []string{maskUnmatched(r1, matched1), maskUnmatched(r2, matched2)}
+	return explanation
+}
+
+func maskUnmatched(r []rune, matched []bool) string {
+	masked := make([]rune, len(r))
+	for i := range r {
+		masked[i] = r[i]
+		if !matched[i] {
+			masked[i] = alignmentGap
+		}
+	}
+	return string(masked)
+}
+
+// Describes the explanation in words, one line per operation,
+// or the matches and transpositions for Jaro.
+func (e *Explanation) describe() []string {
+	var lines []string
+	for _, sub := range e.Synonyms1 {
+		lines = append(lines, fmt.Sprintf("replaced synonym '%s' with '%s' in s1", sub.From, sub.To))
+	}
+	for _, sub := range e.Synonyms2 {
+		lines = append(lines, fmt.Sprintf("replaced synonym '%s' with '%s' in s2", sub.From, sub.To))
+	}
+	for _, op := range e.Operations {
+		switch op.Type {
+		case "insert":
+			lines = append(lines, fmt.Sprintf("insert '%s' at index %d", op.S2, op.Position1))
+		case "delete":
+			lines = append(lines, fmt.Sprintf("delete '%s' at index %d", op.S1, op.Position1))
+		case "substitute":
+			lines = append(lines, fmt.Sprintf("substitute '%s' with '%s' at index %d", op.S1, op.S2, op.Position1))
+		case "transpose":
+			lines = append(lines, fmt.Sprintf("transpose '%s' to '%s' at index %d", op.S1, op.S2, op.Position1))
+		default:
+			lines = append(lines, fmt.Sprintf("%s '%s' to '%s' at index %d", op.Type, op.S1, op.S2, op.Position1))
+		}
+	}
+	if e.Matches != nil && len(e.Matches) == 0 {
+		lines = append(lines, "0 characters matched")
+	} else if e.Matches != nil {
+		chars := make([]string, len(e.Matches))
+		for i, m := range e.Matches {
+			chars[i] = m.Character
+		}
+		lines = append(lines, fmt.Sprintf("%d matched characters (%s), %d transpositions", len(e.Matches), strings.Join(chars, ""), e.Transpositions))
+	}
+	if e.Alignment != nil && len(e.Operations) == 0 && e.Matches == nil {
+		lines = append(lines, "no edits")
+	}
+	return lines
+}
+
+func reverseRunes(r []rune) {
+	for l, h := 0, len(r)-1; l < h; l, h = l+1, h-1 {
+		r[l], r[h] = r[h], r[l]
+	}
+}
@@ -0,0 +1,42 @@
+package similarity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplainSimilarity(t *testing.T) {
+	cases := []struct {
+		metric    string
+		s1        string
+		s2        string
+		alignment []string
+		lines     []string
+	}{
+		{"levenshtein", "kitten", "sitting", []string{"kitten-", "sitting"}, []string{
+			"substitute 'k' with 's' at index 0",
+			"substitute 'e' with 'i' at index 4",
+			"insert 'g' at index 6",
+		}},
+		{"levenshtein", "kitten", "kitten", []string{"kitten", "kitten"}, []string{"no edits"}},
+		{"dameraulevenshtein", "ca", "abc", []string{"c-a", "abc"}, []string{
+			"transpose 'ca' to 'ac' at index 0",
+			"insert 'b' at index 1",
+		}},
+		{"hamming", "abcd", "abce", []string{"abcd", "abce"}, []string{"substitute 'd' with 'e' at index 3"}},
+		{"jaro", "martha", "marhta", []string{"martha", "marhta"}, []string{"6 matched characters (martha), 1 transpositions"}},
+		{"jaro", "abc", "abd", []string{"ab-", "ab-"}, []string{"2 matched characters (ab), 0 transpositions"}},
+		{"jaro", "abc", "xyz", []string{"---", "---"}, []string{"0 characters matched"}},
+		{"jaro", "abc", "", []string{"---", ""}, []string{"0 characters matched"}},
+	}
+
+	for _, c := range cases {
+		explanation := explainSimilarity(c.metric, c.s1, c.s2)
+		if !reflect.DeepEqual(explanation.Alignment, c.alignment) {
+			t.Errorf("%s alignment of (%q, %q) = %q, want %q", c.metric, c.s1, c.s2, explanation.Alignment, c.alignment)
+		}
+		if lines := explanation.describe(); !reflect.DeepEqual(lines, c.lines) {
+			t.Errorf("%s explanation of (%q, %q) = %q, want %q", c.metric, c.s1, c.s2, lines, c.lines)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Probabilities below this are raised to it, so no agreement
+// level ends up with a weight of plus or minus infinity.
+const minProbability = 1e-6
+
+// Agreement level of a missing field, which is left out of the
+// estimation and has a weight of 0, as it tells nothing of a match.
+const missingLevel = -1
+
+// Estimated probabilities of each agreement level of a field among
+// the matches (m) and the non-matches (u), and the weight log2(m/u)
+// added to a pair for having that level.
+type FieldParameters struct {
+	Name    string    `json:"name"`
+	M       []float64 `json:"m"`
+	U       []float64 `json:"u"`
+	Weights []float64 `json:"weights"`
+}
+
+type ProbabilisticSimilarity struct {
+	S1          string  `json:"s1"`
+	S2          string  `json:"s2"`
+	Weight      float64 `json:"weight"`
+	Probability float64 `json:"probability"`
+	// Score of each field, leaving out the missing ones
+	Fields map[string]float64 `json:"fields"`
+}
+
+type ProbabilisticLinkage struct {
+	// Estimated proportion of the pairs that are matches
+	Prevalence float64                   `json:"prevalence"`
+	Parameters []FieldParameters         `json:"parameters"`
+	Pairs      []ProbabilisticSimilarity `json:"pairs"`
+}
+
+// Flow for Fellegi-Sunter probabilistic record linkage. The score of
+// each field is discretized into agreement levels, the probability of
+// each level among matches and non-matches is estimated with
+// expectation-maximization over all pairs, and every pair gets a
+// match weight and the posterior probability of being a match. Only
+// the pairs with a probability of at least the threshold are kept.
+func ProbabilisticLinkFlow(mainRecords []map[string]string, otherRecords []map[string]string, config LinkConfig, threshold float64, iterations int, amountGoroutines int, StringFlags map[string]string, BoolFlags map[string]bool) {
+	config.validate()
+	config.checkColumns(mainRecords, StringFlags["File1"])
+	config.checkColumns(otherRecords, StringFlags["File2"])
+
+	// Without any pair there is nothing to estimate the parameters from
+	if len(mainRecords) == 0 || len(otherRecords) == 0 {
+		fmt.Println("No pairs of records to link, since one of the files has no records")
+		os.Exit(1)
+	}
+
+	mainValues := config.normalizeRecords(mainRecords)
+	otherValues := config.normalizeRecords(otherRecords)
+	calculators := config.calculators()
+
+	nFields := len(config.Fields)
+	nOther := len(otherRecords)
+
+	// Score of every field of every pair, indexed by
+	// [(i*nOther + j)*nFields + field], or NaN when the field
+	// is missing. Each goroutine writes its own cells so no
+	// lock is needed.
+	scores := make([]float64, len(mainRecords)*nOther*nFields)
+
+	var wg sync.WaitGroup
+
+	// Add the amount of goroutines to the wait group
+	wg.Add(amountGoroutines)
+
+	// Each goroutine takes every 'amountGoroutines'th
+	// of the other records
+	for g := 0; g < amountGoroutines; g++ {
+		go func(first int) {
+			for j := first; j < nOther; j += amountGoroutines {
+				for i := range mainRecords {
+					for f := range config.Fields {
+						score, ok := fieldScore(calculators[f], mainValues[i][f], otherValues[j][f])
+						if !ok {
+							score = math.NaN()
+						}
+						scores[(i*nOther+j)*nFields+f] = score
+					}
+				}
+			}
+			// Done with this goroutine
+			wg.Done()
+		}(g)
+	}
+
+	// Wait for all goroutines to finish
+	wg.Wait()
+
+	// Many pairs share the same agreement levels on every field,
+	// so EM runs over the distinct patterns and how often they occur.
+	patternOf := make([]int, len(scores)/nFields)
+	patternIndex := make(map[string]int)
+	var patterns [][]int
+	var patternCounts []float64
+	for p := range patternOf {
+		levels := make([]int, nFields)
+		for f, field := range config.Fields {
+			levels[f] = agreementLevel(scores[p*nFields+f], field.Levels)
+		}
+		key := fmt.Sprint(levels)
+		index, ok := patternIndex[key]
+		if !ok {
+			index = len(patterns)
+			patternIndex[key] = index
+			patterns = append(patterns, levels)
+			patternCounts = append(patternCounts, 0)
+		}
+		patternCounts[index]++
+		patternOf[p] = index
+	}
+
+	prevalence, m, u := estimateParameters(patterns, patternCounts, config, iterations)
+
+	// Weight and posterior probability of each pattern
+	patternWeights := make([]float64, len(patterns))
+	patternProbabilities := make([]float64, len(patterns))
+	for k, levels := range patterns {
+		for f, level := range levels {
+			if level != missingLevel {
+				patternWeights[k] += math.Log2(m[f][level] / u[f][level])
+			}
+		}
+		patternProbabilities[k] = matchProbability(levels, prevalence, m, u)
+	}
+
+	linkage := ProbabilisticLinkage{Prevalence: prevalence}
+	for f, field := range config.Fields {
+		parameters := FieldParameters{Name: field.Name, M: m[f], U: u[f]}
+		for level := range m[f] {
+			parameters.Weights = append(parameters.Weights, math.Log2(m[f][level]/u[f][level]))
+		}
+		linkage.Parameters = append(linkage.Parameters, parameters)
+	}
+
+	for p, k := range patternOf {
+		if patternProbabilities[k] < threshold {
+			continue
+		}
+		i, j := p/nOther, p%nOther
+		fields := make(map[string]float64, nFields)
+		for f, field := range config.Fields {
+			if score := scores[p*nFields+f]; !math.IsNaN(score) {
+				fields[field.Name] = score
+			}
+		}
+		linkage.Pairs = append(linkage.Pairs, ProbabilisticSimilarity{
+			S1:          config.label(mainRecords[i]),
+			S2:          config.label(otherRecords[j]),
+			Weight:      patternWeights[k],
+			Probability: patternProbabilities[k],
+			Fields:      fields,
+		})
+	}
+
+	// Sort the pairs by weight
+	sort.SliceStable(linkage.Pairs, func(i, j int) bool {
+		return linkage.Pairs[i].Weight > linkage.Pairs[j].Weight
+	})
+
+	// One column for the weight, the probability and each field
+	header := []string{"s1", "s2", "weight", "probability"}
+	for _, field := range config.Fields {
+		header = append(header, field.Name)
+	}
+	rows := make([][]string, len(linkage.Pairs))
+	for i, pair := range linkage.Pairs {
+		rows[i] = []string{pair.S1, pair.S2, fmt.Sprintf("%f", pair.Weight), fmt.Sprintf("%f", pair.Probability)}
+		for _, field := range config.Fields {
+			rows[i] = append(rows[i], formatFieldScore(pair.Fields, field.Name))
+		}
+	}
+
+	// Now check if its not set to silent to print results,
+	// starting with the estimated parameters
+	if !BoolFlags["Silent"] {
+		fmt.Printf("Estimated prevalence of matches: %f\n\n", prevalence)
+		printTable(parametersHeader(), parametersRows(linkage.Parameters, config))
+		fmt.Println()
+		printTable(header, rows)
+	}
+
+	// Check if output to write to file. A json holds the parameters
+	// and the pairs, while a csv only holds the pairs.
+	if StringFlags["Output"] != "" {
+		writeTableToFile(StringFlags["Output"], linkage, header, rows)
+	}
+}
+
+// Index of the first level whose minimum the score reaches, the
+// last level if it's below all of them, or the missing level for
+// the NaN of a missing field.
+func agreementLevel(score float64, levels []float64) int {
+	if math.IsNaN(score) {
+		return missingLevel
+	}
+	for l, min := range levels {
+		if score >= min {
+			return l
+		}
+	}
+	return len(levels)
+}
+
+// Expectation-maximization of the prevalence of matches and the m and
+// u probabilities of each level of each field, indexed by [field][level],
+// assuming the fields are independent given the match status. The
+// probabilities of a field are estimated from the pairs where it's
+// not missing.
+func estimateParameters(patterns [][]int, counts []float64, config LinkConfig, iterations int) (float64, [][]float64, [][]float64) {
+	// Start assuming few matches, which mostly agree on every
+	// field, while non-matches mostly disagree.
+	prevalence := 0.1
+	m := make([][]float64, len(config.Fields))
+	u := make([][]float64, len(config.Fields))
+	for f, field := range config.Fields {
+		nLevels := len(field.Levels) + 1
+		m[f] = make([]float64, nLevels)
+		u[f] = make([]float64, nLevels)
+		for level := 0; level < nLevels; level++ {
+			m[f][level] = float64(nLevels-level) / float64(nLevels*(nLevels+1)/2)
+			u[f][level] = float64(level+1) / float64(nLevels*(nLevels+1)/2)
+		}
+	}
+
+	total := 0.0
+	for _, count := range counts {
+		total += count
+	}
+
+	for iteration := 0; iteration < iterations; iteration++ {
+		// Expectation: probability of each pattern being a match
+		posteriors := make([]float64, len(patterns))
+		for k, levels := range patterns {
+			posteriors[k] = matchProbability(levels, prevalence, m, u)
+		}
+
+		// Maximization: the expected share of matches and of each
+		// level among the matches and the non-matches, where the
+		// field isn't missing
+		matches := 0.0
+		newM := make([][]float64, len(m))
+		newU := make([][]float64, len(u))
+		fieldMatches := make([]float64, len(m))
+		fieldNonMatches := make([]float64, len(m))
+		for f := range m {
+			newM[f] = make([]float64, len(m[f]))
+			newU[f] = make([]float64, len(u[f]))
+		}
+		for k, levels := range patterns {
+			matches += counts[k] * posteriors[k]
+			for f, level := range levels {
+				if level == missingLevel {
+					continue
+				}
+				newM[f][level] += counts[k] * posteriors[k]
+				newU[f][level] += counts[k] * (1 - posteriors[k])
+				fieldMatches[f] += counts[k] * posteriors[k]
+				fieldNonMatches[f] += counts[k] * (1 - posteriors[k])
+			}
+		}
+
+		change := math.Abs(matches/total - prevalence)
+		prevalence = clampProbability(matches / total)
+		for f := range m {
+			for level := range m[f] {
+				mf := clampProbability(newM[f][level] / math.Max(fieldMatches[f], minProbability))
+				uf := clampProbability(newU[f][level] / math.Max(fieldNonMatches[f], minProbability))
+				change = math.Max(change, math.Abs(mf-m[f][level]))
+				change = math.Max(change, math.Abs(uf-u[f][level]))
+				m[f][level] = mf
+				u[f][level] = uf
+			}
+		}
+
+		// Stop once the parameters barely change
+		if change < 1e-6 {
+			break
+		}
+	}
+
+	return prevalence, m, u
+}
+
+// Posterior probability of a pair with the given agreement levels
+// being a match, computed from the log odds to avoid underflows.
+// Missing fields don't change the odds.
+func matchProbability(levels []int, prevalence float64, m [][]float64, u [][]float64) float64 {
+	logOdds := math.Log(prevalence) - math.Log(1-prevalence)
+	for f, level := range levels {
+		if level != missingLevel {
+			logOdds += math.Log(m[f][level]) - math.Log(u[f][level])
+		}
+	}
+	return 1 / (1 + math.Exp(-logOdds))
+}
+
+func clampProbability(p float64) float64 {
+	return math.Min(math.Max(p, minProbability), 1-minProbability)
+}
+
+func parametersHeader() []string {
+	return []string{"field", "level", "m", "u", "weight"}
+}
+
+// One row for each level of each field, the level shown
+// as the range of scores that falls into it, and one for
+// the missing level, which always weighs 0.
+func parametersRows(parameters []FieldParameters, config LinkConfig) [][]string {
+	var rows [][]string
+	for f, field := range config.Fields {
+		for level := range parameters[f].M {
+			var label string
+			if level < len(field.Levels) {
+				label = fmt.Sprintf(">= %g", field.Levels[level])
+			} else {
+				label = fmt.Sprintf("< %g", field.Levels[len(field.Levels)-1])
+			}
+			rows = append(rows, []string{
+				field.Name,
+				label,
+				fmt.Sprintf("%f", parameters[f].M[level]),
+				fmt.Sprintf("%f", parameters[f].U[level]),
+				fmt.Sprintf("%f", parameters[f].Weights[level]),
+			})
+		}
+		rows = append(rows, []string{field.Name, "missing", "", "", fmt.Sprintf("%f", 0.0)})
+	}
+	return rows
+}
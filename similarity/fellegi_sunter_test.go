@@ -0,0 +1,102 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAgreementLevel(t *testing.T) {
+	levels := []float64{0.95, 0.85}
+
+	cases := []struct {
+		score float64
+		want  int
+	}{
+		{1, 0},
+		{0.95, 0},
+		{0.9, 1},
+		{0.85, 1},
+		{0.5, 2},
+		{0, 2},
+		// A missing field is its own level, never the lowest one
+		{math.NaN(), missingLevel},
+	}
+
+	for _, c := range cases {
+		if got := agreementLevel(c.score, levels); got != c.want {
+			t.Errorf("agreementLevel(%v) = %d, want %d", c.score, got, c.want)
+		}
+	}
+}
+
+// Missing fields leave the odds of the prevalence unchanged.
+func TestMatchProbability(t *testing.T) {
+	m := [][]float64{{0.9, 0.1}, {0.8, 0.2}}
+	u := [][]float64{{0.1, 0.9}, {0.2, 0.8}}
+
+	cases := []struct {
+		levels []int
+		want   float64
+	}{
+		{[]int{missingLevel, missingLevel}, 0.1},
+		{[]int{0, missingLevel}, 0.5},
+		{[]int{1, missingLevel}, 1.0 / 82},
+		{[]int{0, 0}, 0.8},
+		{[]int{1, 1}, 1.0 / 325},
+	}
+
+	for _, c := range cases {
+		if got := matchProbability(c.levels, 0.1, m, u); math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("matchProbability(%v) = %v, want %v", c.levels, got, c.want)
+		}
+	}
+}
+
+// Pairs agreeing on both fields are the matches, a tenth of the pairs,
+// and a missing field doesn't move the estimates of the other one.
+func TestEstimateParameters(t *testing.T) {
+	config := LinkConfig{Fields: []LinkField{
+		{Name: "name", Levels: []float64{0.9}},
+		{Name: "city", Levels: []float64{0.9}},
+	}}
+	patterns := [][]int{{0, 0}, {1, 1}, {0, 1}, {1, 0}, {missingLevel, 1}}
+	counts := []float64{100, 800, 50, 50, 100}
+
+	prevalence, m, u := estimateParameters(patterns, counts, config, 1000)
+
+	if math.Abs(prevalence-100.0/1100) > 0.02 {
+		t.Errorf("prevalence = %v, want about %v", prevalence, 100.0/1100)
+	}
+	for f, field := range config.Fields {
+		if m[f][0] < 0.8 || u[f][0] > 0.1 {
+			t.Errorf("field %s has m %v and u %v, want agreement likely among matches only", field.Name, m[f], u[f])
+		}
+		if math.Abs(m[f][0]+m[f][1]-1) > 1e-6 || math.Abs(u[f][0]+u[f][1]-1) > 1e-6 {
+			t.Errorf("field %s has m %v and u %v, not adding up to 1", field.Name, m[f], u[f])
+		}
+	}
+}
+
+func TestParametersRows(t *testing.T) {
+	config := LinkConfig{Fields: []LinkField{{Name: "name", Levels: []float64{0.95, 0.85}}}}
+	parameters := []FieldParameters{{Name: "name", M: []float64{0.8, 0.15, 0.05}, U: []float64{0.05, 0.15, 0.8}, Weights: []float64{4, 0, -4}}}
+
+	want := [][]string{
+		{"name", ">= 0.95", "0.800000", "0.050000", "4.000000"},
+		{"name", ">= 0.85", "0.150000", "0.150000", "0.000000"},
+		{"name", "< 0.85", "0.050000", "0.800000", "-4.000000"},
+		{"name", "missing", "", "", "0.000000"},
+	}
+	got := parametersRows(parameters, config)
+	if len(got) != len(want) {
+		t.Fatalf("parametersRows = %q, want %q", got, want)
+	}
+	for i := range want {
+		for j := range want[i] {
+			if got[i][j] != want[i][j] {
+				t.Errorf("parametersRows row %d = %q, want %q", i, got[i], want[i])
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package similarity
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// What Hamming does with strings of different lengths.
+var hammingPolicy = "fail"
+
+// Sets what Hamming does with strings of different lengths: skip the
+// pair, pad the shorter string with spaces, count each missing character
+// as a mismatch or fail. Exits on any other policy.
+func SetHammingPolicy(policy string) {
+	policy = strings.ToLower(policy)
+	switch policy {
+	case "skip", "pad", "count", "fail":
+		hammingPolicy = policy
+	default:
+		fmt.Println("Hamming policy not supported. Available: skip, pad, count, fail")
+		os.Exit(1)
+	}
+}
+
+// Checks if the pair is left out of the results, which only
+// Hamming does, for strings of different lengths.
+func skipsPair(metric string, s1 string, s2 string) bool {
+	return metric == "hamming" && hammingPolicy == "skip" && utf8.RuneCountInString(s1) != utf8.RuneCountInString(s2)
+}
+
+// Checks if the metric stops the run on strings of different
+// lengths, which only Hamming does when told to fail.
+func failsOnLengths(metric string) bool {
+	return metric == "hamming" && hammingPolicy == "fail"
+}
+
+// Checks if the metric would stop the run on the pair.
+func failsOnPair(metric string, s1 string, s2 string) bool {
+	return failsOnLengths(metric) && utf8.RuneCountInString(s1) != utf8.RuneCountInString(s2)
+}
+
+// Checks if the metric would stop the run on any of the pairs.
+func failsOnAnyPair(metric string, pairs []LabeledPair) bool {
+	for _, pair := range pairs {
+		if failsOnPair(metric, pair.S1, pair.S2) {
+			return true
+		}
+	}
+	return false
+}
+
+// A pair of an s1 and an s2 the metric would stop the run on, if any.
+// Only the first string of each side has to be compared to the other
+// side, since if all s2s have the length of the first s1, any pair
+// with a different length has an s1 of another length.
+func failingPair(metric string, strs1 []string, strs2 []string) (string, string, bool) {
+	if !failsOnLengths(metric) || len(strs1) == 0 || len(strs2) == 0 {
+		return "", "", false
+	}
+	for _, s2 := range strs2 {
+		if failsOnPair(metric, strs1[0], s2) {
+			return strs1[0], s2, true
+		}
+	}
+	for _, s1 := range strs1 {
+		if failsOnPair(metric, s1, strs2[0]) {
+			return s1, strs2[0], true
+		}
+	}
+	return "", "", false
+}
+
+// Exits if the metric would stop the run on any pair of an s1 and
+// an s2. Flows call it before scoring, so the run never stops from
+// inside their goroutines, cutting off the output.
+func checkPairs(metric string, strs1 []string, strs2 []string) {
+	if s1, s2, ok := failingPair(metric, strs1, strs2); ok {
+		reportFailure(s1, s2)
+		os.Exit(1)
+	}
+}
+
+func reportFailure(s1 string, s2 string) {
+	fmt.Printf("Hamming can't compare strings of different lengths, '%s' and '%s'. Use --hamming-policy to skip, pad or count them\n", s1, s2)
+}
+
+// Hamming distance, the amount of positions with different characters.
+// Strings of different lengths are handled by the policy. The flows
+// that can't leave a pair out, like choosing the central member of a
+// cluster, count the missing characters when the policy is to skip.
+// Every flow checks the pairs before scoring them when the policy is
+// to fail, so then the missing characters are never reached.
+func hammingDistance(s1 string, s2 string) float64 {
+	r1, r2 := []rune(s1), []rune(s2)
+	if len(r1) > len(r2) {
+		r1, r2 = r2, r1
+	}
+
+	distance := len(r2) - len(r1)
+	if distance > 0 && hammingPolicy == "pad" {
+		// Spaces at the end of the longer string match the padding
+		distance = 0
+		for _, c := range r2[len(r1):] {
+			if c != ' ' {
+				distance++
+			}
+		}
+	}
+
+	for i, c := range r1 {
+		if r2[i] != c {
+			distance++
+		}
+	}
+	return float64(distance)
+}
+
+// Pairs a flow left out since the metric can't score them, reported
+// after the results. Can be added to from many goroutines.
+type skippedPairs struct {
+	mu    sync.Mutex
+	count int
+	// The first one, given as an example
+	s1 string
+	s2 string
+}
+
+func (s *skippedPairs) add(s1 string, s2 string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.count == 0 {
+		s.s1, s.s2 = s1, s2
+	}
+	s.count++
+}
+
+func (s *skippedPairs) empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.count == 0
+}
+
+// Prints how many pairs were skipped, if any.
+func (s *skippedPairs) report(metric string) {
+	if s.count == 0 {
+		return
+	}
+	fmt.Printf("\n%s skipped %d of the pairs, whose strings have different lengths, like '%s' and '%s'\n", getPrettyMetricName(metric), s.count, s.s1, s.s2)
+}
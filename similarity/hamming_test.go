@@ -0,0 +1,98 @@
+package similarity
+
+import "testing"
+
+func TestHammingDistance(t *testing.T) {
+	defer SetHammingPolicy("fail")
+
+	cases := []struct {
+		policy string
+		s1     string
+		s2     string
+		want   float64
+	}{
+		{"fail", "", "", 0},
+		{"fail", "karolin", "kathrin", 3},
+		{"fail", "São", "Sao", 1},
+		{"count", "abc", "abcde", 2},
+		{"count", "abcde", "abc", 2},
+		{"count", "", "abc", 3},
+		{"count", "abc", "xbcd", 2},
+		{"pad", "abc", "abcde", 2},
+		{"pad", "abc", "abc  ", 0},
+		{"pad", "abc", "xbc d", 2},
+		{"pad", "", "  ", 0},
+		// Pairs that are skipped in the flows count the missing characters elsewhere
+		{"skip", "abc", "abcde", 2},
+		{"SKIP", "abc", "xbc", 1},
+	}
+
+	for _, c := range cases {
+		SetHammingPolicy(c.policy)
+		if got := hammingDistance(c.s1, c.s2); got != c.want {
+			t.Errorf("hammingDistance(%q, %q) with %s = %v, want %v", c.s1, c.s2, c.policy, got, c.want)
+		}
+	}
+}
+
+func TestHammingPolicies(t *testing.T) {
+	defer SetHammingPolicy("fail")
+
+	cases := []struct {
+		policy string
+		metric string
+		s1     string
+		s2     string
+		skips  bool
+		fails  bool
+	}{
+		{"fail", "hamming", "abc", "abc", false, false},
+		{"fail", "hamming", "abc", "abcd", false, true},
+		{"fail", "hamming", "São", "Sao", false, false},
+		{"fail", "levenshtein", "abc", "abcd", false, false},
+		{"skip", "hamming", "abc", "abcd", true, false},
+		{"skip", "hamming", "", "", false, false},
+		{"skip", "jaro", "abc", "abcd", false, false},
+		{"pad", "hamming", "abc", "abcd", false, false},
+		{"count", "hamming", "abc", "abcd", false, false},
+	}
+
+	for _, c := range cases {
+		SetHammingPolicy(c.policy)
+		if got := skipsPair(c.metric, c.s1, c.s2); got != c.skips {
+			t.Errorf("skipsPair(%s, %q, %q) with %s = %v, want %v", c.metric, c.s1, c.s2, c.policy, got, c.skips)
+		}
+		if got := failsOnPair(c.metric, c.s1, c.s2); got != c.fails {
+			t.Errorf("failsOnPair(%s, %q, %q) with %s = %v, want %v", c.metric, c.s1, c.s2, c.policy, got, c.fails)
+		}
+	}
+}
+
+func TestFailingPair(t *testing.T) {
+	defer SetHammingPolicy("fail")
+
+	cases := []struct {
+		policy string
+		strs1  []string
+		strs2  []string
+		s1     string
+		s2     string
+		ok     bool
+	}{
+		{"fail", []string{"abc", "xyz"}, []string{"abd", "xyw"}, "", "", false},
+		{"fail", []string{"abc"}, []string{"abd", "ab"}, "abc", "ab", true},
+		{"fail", []string{"abc", "abcd"}, []string{"abd"}, "abcd", "abd", true},
+		{"fail", nil, []string{"abd"}, "", "", false},
+		{"fail", []string{"abc"}, []string{}, "", "", false},
+		{"count", []string{"abc"}, []string{"abd", "ab"}, "", "", false},
+		{"skip", []string{"abc", "abcd"}, []string{"abd"}, "", "", false},
+	}
+
+	for _, c := range cases {
+		SetHammingPolicy(c.policy)
+		s1, s2, ok := failingPair("hamming", c.strs1, c.strs2)
+		if s1 != c.s1 || s2 != c.s2 || ok != c.ok {
+			t.Errorf("failingPair(%q, %q) with %s = %q, %q, %v, want %q, %q, %v", c.strs1, c.strs2, c.policy, s1, s2, ok, c.s1, c.s2, c.ok)
+		}
+	}
+}
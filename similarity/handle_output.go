@@ -4,25 +4,103 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+
+	"github.com/mtrentz/stringsim/utils"
 )
 
+// Header of the csv outputs of similarities, matching csvRecord.
+var similarityCsvHeader = []string{"metric", "s1", "s2", "score", "s1_normalized", "s2_normalized", "s1_start", "s1_end", "s2_start", "s2_end", "substring"}
+
+// The similarity as a row of the csv outputs. The region
+// columns are left empty for metrics that don't align.
+func (s *Similarity) csvRecord() []string {
+	record := []string{s.Metric, s.S1, s.S2, fmt.Sprintf("%f", s.Score), s.S1Normalized, s.S2Normalized, "", "", "", "", ""}
+	if s.Region != nil {
+		record[6] = strconv.Itoa(s.Region.Start1)
+		record[7] = strconv.Itoa(s.Region.End1)
+		record[8] = strconv.Itoa(s.Region.Start2)
+		record[9] = strconv.Itoa(s.Region.End2)
+		record[10] = s.Region.Substring
+	}
+	return record
+}
+
 func printResults(similarities []Similarity) {
+	// The normalized strings are only shown when
+	// they differ from the originals somewhere
+	showNormalized := false
+	for _, similarity := range similarities {
+		if similarity.S1Normalized != similarity.S1 || similarity.S2Normalized != similarity.S2 {
+			showNormalized = true
+			break
+		}
+	}
+	// Same for the common substring, which only
+	// the longest common substring metrics find
+	showSubstring := false
+	for _, similarity := range similarities {
+		if similarity.Region != nil && similarity.Region.Substring != "" {
+			showSubstring = true
+			break
+		}
+	}
+
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "metric\ts1\ts2\tscore\n")
+	header := "metric\ts1\ts2\tscore"
+	if showNormalized {
+		header += "\ts1_normalized\ts2_normalized"
+	}
+	if len(similarities) > 0 && similarities[0].Region != nil {
+		header += "\ts1_region\ts2_region"
+	}
+	if showSubstring {
+		header += "\tsubstring"
+	}
+	fmt.Fprintln(w, header)
 	for _, similarity := range similarities {
-		fmt.Fprintf(w, "%s\t%s\t%s\t%f\n", similarity.Metric, similarity.S1, similarity.S2, similarity.Score)
+		row := fmt.Sprintf("%s\t%s\t%s\t%f", similarity.Metric, similarity.S1, similarity.S2, similarity.Score)
+		if showNormalized {
+			row += fmt.Sprintf("\t%s\t%s", similarity.S1Normalized, similarity.S2Normalized)
+		}
+		// Aligned part of each string, from the start to the end offset
+		if region := similarity.Region; region != nil {
+			row += fmt.Sprintf("\t%d:%d\t%d:%d", region.Start1, region.End1, region.Start2, region.End2)
+		}
+		if showSubstring {
+			row += "\t" + similarity.Region.Substring
+		}
+		fmt.Fprintln(w, row)
 	}
 	w.Flush()
+
+	// Explanations go after the table, one block for each
+	// pair with the alignment and the operations in words
+	for _, similarity := range similarities {
+		if similarity.Explanation == nil {
+			continue
+		}
+		fmt.Printf("\n%s -> %s (%s %f)\n", similarity.S1, similarity.S2, similarity.Metric, similarity.Score)
+		for _, line := range similarity.Explanation.Alignment {
+			fmt.Printf("  %s\n", line)
+		}
+		for _, line := range similarity.Explanation.describe() {
+			fmt.Printf("  %s\n", line)
+		}
+	}
 }
 
 // Detect if output is to json or csv, write it all at once,
 // which works for the smaller files that everything is hold in memory.
+// The output is compressed if the filename ends in .gz or .zst.
 func writeToFile(filename string, similarities []Similarity) {
 	// Check the extension
-	ext := filepath.Ext(filename)
+	ext := utils.FileExt(filename)
 
 	// If the extension is .json, write to json
 	if ext == ".json" {
@@ -45,17 +123,13 @@ func writeToFile(filename string, similarities []Similarity) {
 // Writes a list of similarities to a json file as a list.
 func writeToJson(filename string, similarities []Similarity) {
 	// Check if extension is already .json, else add it
-	if ext := filepath.Ext(filename); ext != ".json" {
+	if ext := utils.FileExt(filename); ext != ".json" {
 		filename = filename + ".json"
 	}
 
 	// Create file
-	file, err := os.Create(filename)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	defer file.Close()
+	file := utils.NewFileWriter(filename)
+	defer closeWriter(file)
 
 	j, err := json.MarshalIndent(similarities, "", "  ")
 	if err != nil {
@@ -64,35 +138,34 @@ func writeToJson(filename string, similarities []Similarity) {
 	}
 
 	// Write to file
-	file.Write(j)
+	if _, err := file.Write(j); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 }
 
 // Write file to CSV all at once including headers.
 func writeToCsv(filename string, similarities []Similarity) {
 	// Check if extension is already .csv, else add it
-	if ext := filepath.Ext(filename); ext != ".csv" {
+	if ext := utils.FileExt(filename); ext != ".csv" {
 		filename = filename + ".csv"
 	}
 
 	// Create file
-	file, err := os.Create(filename)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
-	defer file.Close()
+	file := utils.NewFileWriter(filename)
+	defer closeWriter(file)
 
 	csvWriter := csv.NewWriter(file)
 
 	// Write header
-	csvWriter.Write([]string{"metric", "s1", "s2", "score"})
+	csvWriter.Write(similarityCsvHeader)
 
 	// Write similarities
 	for _, similarity := range similarities {
-		csvWriter.Write([]string{similarity.Metric, similarity.S1, similarity.S2, fmt.Sprintf("%f", similarity.Score)})
+		csvWriter.Write(similarity.csvRecord())
 	}
 
-	csvWriter.Flush()
+	flushCsv(csvWriter)
 }
 
 // By the filename, either create and empty csv
@@ -136,7 +209,7 @@ func createEmptyCsvFile(filename string) {
 
 	// Write header
 	csvWriter := csv.NewWriter(file)
-	csvWriter.Write([]string{"metric", "s1", "s2", "score"})
+	csvWriter.Write(similarityCsvHeader)
 	csvWriter.Flush()
 }
 
@@ -226,7 +299,7 @@ func appendToJsonArray(file *os.File, similarity *Similarity, isEmpty bool) {
 func appendToCsv(file *os.File, similarity *Similarity) {
 	file.Seek(0, 2)
 	w := csv.NewWriter(file)
-	w.Write([]string{similarity.Metric, similarity.S1, similarity.S2, fmt.Sprintf("%f", similarity.Score)})
+	w.Write(similarity.csvRecord())
 	w.Flush()
 }
 
@@ -264,3 +337,130 @@ func readByteAsString(file *os.File) string {
 	}
 	return string(b)
 }
+
+// Writes similarities one at a time to a compressed output file.
+// A compressed stream can't be seeked like appendToJsonArray does,
+// so the json array is opened when the writer is created and only
+// closed after the last similarity was written.
+type streamWriter struct {
+	file      io.WriteCloser
+	ext       string
+	csvWriter *csv.Writer
+	isEmpty   bool
+}
+
+// Create the output file and write the csv header
+// or the opening bracket of the json array.
+func newStreamWriter(filename string) *streamWriter {
+	ext := utils.FileExt(filename)
+	if ext != ".json" && ext != ".csv" {
+		fmt.Println("File extension is not .json or .csv")
+		os.Exit(1)
+	}
+
+	s := &streamWriter{
+		file:    utils.NewFileWriter(filename),
+		ext:     ext,
+		isEmpty: true,
+	}
+
+	if ext == ".json" {
+		s.file.Write([]byte("["))
+	} else {
+		s.csvWriter = csv.NewWriter(s.file)
+		s.csvWriter.Write(similarityCsvHeader)
+	}
+
+	return s
+}
+
+// Write a single similarity to the output.
+func (s *streamWriter) append(similarity *Similarity) {
+	if s.ext == ".csv" {
+		s.csvWriter.Write(similarity.csvRecord())
+		return
+	}
+
+	j, _ := json.Marshal(similarity)
+	if !s.isEmpty {
+		s.file.Write([]byte(","))
+	}
+	s.file.Write(j)
+	s.isEmpty = false
+}
+
+// Close the json array or flush the csv, then
+// finish the compressed stream and close the file.
+func (s *streamWriter) close() {
+	if s.ext == ".csv" {
+		flushCsv(s.csvWriter)
+	} else if _, err := s.file.Write([]byte("]\n")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	closeWriter(s.file)
+}
+
+// Prints any other kind of result, that isn't a list of
+// similarities, as a header and rows aligned in columns.
+func printTable(header []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// Writes any other kind of result, that isn't a list of
+// similarities, to a json or csv file all at once. For json 'v'
+// is marshalled as is, for csv the header is followed by the rows.
+func writeTableToFile(filename string, v interface{}, header []string, rows [][]string) {
+	// Check the extension
+	ext := utils.FileExt(filename)
+	if ext != ".json" && ext != ".csv" {
+		fmt.Println("File extension is not .json or .csv")
+		os.Exit(1)
+	}
+
+	// Create file
+	file := utils.NewFileWriter(filename)
+	defer closeWriter(file)
+
+	if ext == ".json" {
+		j, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if _, err := file.Write(j); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	csvWriter := csv.NewWriter(file)
+	csvWriter.Write(header)
+	csvWriter.WriteAll(rows)
+	flushCsv(csvWriter)
+}
+
+// Flushes a csv writer, exiting on any error it had writing.
+func flushCsv(csvWriter *csv.Writer) {
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
+
+// Closes a file that was written to, exiting on errors. For
+// compressed files closing writes the end of the stream, so
+// the file would be cut short if it fails.
+func closeWriter(file io.Closer) {
+	if err := file.Close(); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
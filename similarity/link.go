@@ -0,0 +1,291 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// How records are compared, read from a json config file like
+// {"id": "id", "fields": [{"name": "name", "metric": "jaro", "weight": 2}]}
+type LinkConfig struct {
+	// Field used to identify each record in the output. If
+	// empty, the compared fields are joined by " | " instead.
+	Id     string      `json:"id"`
+	Fields []LinkField `json:"fields"`
+}
+
+type LinkField struct {
+	Name   string `json:"name"`
+	Metric string `json:"metric"`
+	// Nil when not set, defaulting to 1, so 0 turns the field off
+	Weight      *float64 `json:"weight"`
+	Insensitive bool     `json:"insensitive"`
+	Unidecode   bool     `json:"unidecode"`
+	// Steps applied after the lowercase and unidecode above,
+	// the same as the ones of the normalize flag
+	Normalize []string `json:"normalize"`
+	// Descending minimum scores of each agreement level used by
+	// the probabilistic linkage, the last level being anything
+	// below the smallest one. Defaults to [0.95, 0.85].
+	Levels []float64 `json:"levels"`
+
+	normalizer *utils.Normalizer
+}
+
+type RecordSimilarity struct {
+	S1    string  `json:"s1"`
+	S2    string  `json:"s2"`
+	Score float64 `json:"score"`
+	// Score of each field, leaving out the missing ones
+	Fields map[string]float64 `json:"fields"`
+}
+
+// Checks the config and fills in its defaults, the metric
+// defaults to Jaro, the weight to 1 and the levels to
+// [0.95, 0.85], and builds the normalizer of each field.
+// Exits on errors.
+func (c *LinkConfig) validate() {
+	if len(c.Fields) == 0 {
+		fmt.Println("Link config has no fields")
+		os.Exit(1)
+	}
+	for i := range c.Fields {
+		field := &c.Fields[i]
+		if field.Name == "" {
+			fmt.Println("Link config has a field without a name")
+			os.Exit(1)
+		}
+		field.Metric = strings.ToLower(field.Metric)
+		if field.Metric == "" {
+			field.Metric = "jaro"
+		}
+		if !IsRatioMetric(field.Metric) {
+			fmt.Printf("Metric of field %s has to score from 0 to 1 to be combined, like Jaro or LevenshteinRatio\n", field.Name)
+			os.Exit(1)
+		}
+		if field.Weight == nil {
+			weight := 1.0
+			field.Weight = &weight
+		}
+		if *field.Weight < 0 {
+			fmt.Printf("Weight of field %s can't be negative\n", field.Name)
+			os.Exit(1)
+		}
+		if len(field.Levels) == 0 {
+			field.Levels = []float64{0.95, 0.85}
+		}
+		for l := 1; l < len(field.Levels); l++ {
+			if field.Levels[l] >= field.Levels[l-1] {
+				fmt.Printf("Levels of field %s have to be in descending order\n", field.Name)
+				os.Exit(1)
+			}
+		}
+
+		var steps []string
+		if field.Insensitive {
+			steps = append(steps, "lowercase")
+		}
+		if field.Unidecode {
+			steps = append(steps, "unidecode")
+		}
+		field.normalizer = utils.NewNormalizer(append(steps, field.Normalize...))
+	}
+}
+
+// Checks that the id and every compared field are columns of the
+// records, found in at least one of them, so a typo in the config
+// doesn't compare empty values on every pair. Exits otherwise.
+func (c *LinkConfig) checkColumns(records []map[string]string, filename string) {
+	if len(records) == 0 {
+		return
+	}
+	var names []string
+	if c.Id != "" {
+		names = append(names, c.Id)
+	}
+	for _, field := range c.Fields {
+		names = append(names, field.Name)
+	}
+	for _, name := range names {
+		found := false
+		for _, record := range records {
+			if _, ok := record[name]; ok {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fmt.Printf("Field %s of the link config is not a column of %s\n", name, filename)
+			os.Exit(1)
+		}
+	}
+}
+
+// Normalizes the compared fields of every record once, instead of
+// once per pair. Returns the values indexed by [record][field].
+func (c *LinkConfig) normalizeRecords(records []map[string]string) [][]string {
+	values := make([][]string, len(records))
+	for r, record := range records {
+		values[r] = make([]string, len(c.Fields))
+		for i := range c.Fields {
+			values[r][i] = c.Fields[i].normalizer.Normalize(record[c.Fields[i].Name])
+		}
+	}
+	return values
+}
+
+// Score of a field of a pair of records, or false if the field is
+// missing, when either value is empty or the metric can't score them,
+// like LevenshteinRatio on two empty strings. Missing fields tell
+// nothing about the pair, so they are left out of its score.
+func fieldScore(calculateSimilarity func(string, string) float64, v1 string, v2 string) (float64, bool) {
+	if v1 == "" || v2 == "" {
+		return 0, false
+	}
+	score := calculateSimilarity(v1, v2)
+	if math.IsNaN(score) {
+		return 0, false
+	}
+	return score, true
+}
+
+// Formats the score of a field, or an empty cell if it's missing.
+func formatFieldScore(fields map[string]float64, name string) string {
+	score, ok := fields[name]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%f", score)
+}
+
+// The function calculating the score of each field.
+func (c *LinkConfig) calculators() []func(string, string) float64 {
+	calculators := make([]func(string, string) float64, len(c.Fields))
+	for i, field := range c.Fields {
+		calculators[i] = getSimilarityFunc(field.Metric)
+	}
+	return calculators
+}
+
+// Identifies a record in the output.
+func (c *LinkConfig) label(record map[string]string) string {
+	if c.Id != "" {
+		return record[c.Id]
+	}
+	values := make([]string, len(c.Fields))
+	for i, field := range c.Fields {
+		values[i] = record[field.Name]
+	}
+	return strings.Join(values, " | ")
+}
+
+// Flow for linking records made of many fields. Every field is
+// compared with its own metric and the score of a pair of records
+// is the weighted mean of the field scores, leaving out the missing
+// fields. Pairs missing every weighted field have no score and are
+// left out. Only the pairs with a combined score of at least the
+// threshold are kept, sorted by score.
+func LinkFlow(mainRecords []map[string]string, otherRecords []map[string]string, config LinkConfig, threshold float64, amountGoroutines int, StringFlags map[string]string, BoolFlags map[string]bool) {
+	config.validate()
+	config.checkColumns(mainRecords, StringFlags["File1"])
+	config.checkColumns(otherRecords, StringFlags["File2"])
+
+	// Fields of weight 0 are still shown, but some field has to count
+	hasWeight := false
+	for _, field := range config.Fields {
+		hasWeight = hasWeight || *field.Weight > 0
+	}
+	if !hasWeight {
+		fmt.Println("Link config needs a field with a weight above 0")
+		os.Exit(1)
+	}
+
+	mainValues := config.normalizeRecords(mainRecords)
+	otherValues := config.normalizeRecords(otherRecords)
+	calculators := config.calculators()
+
+	var similarities []RecordSimilarity
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Add the amount of goroutines to the wait group
+	wg.Add(amountGoroutines)
+
+	// Each goroutine takes every 'amountGoroutines'th
+	// of the other records
+	for g := 0; g < amountGoroutines; g++ {
+		go func(first int) {
+			for j := first; j < len(otherRecords); j += amountGoroutines {
+				for i := range mainRecords {
+					fields := make(map[string]float64, len(config.Fields))
+					score, totalWeight := 0.0, 0.0
+					for f, field := range config.Fields {
+						fScore, ok := fieldScore(calculators[f], mainValues[i][f], otherValues[j][f])
+						if !ok {
+							continue
+						}
+						fields[field.Name] = fScore
+						score += *field.Weight * fScore
+						totalWeight += *field.Weight
+					}
+					if totalWeight == 0 {
+						continue
+					}
+					score /= totalWeight
+					if score < threshold {
+						continue
+					}
+
+					similarity := RecordSimilarity{
+						S1:     config.label(mainRecords[i]),
+						S2:     config.label(otherRecords[j]),
+						Score:  score,
+						Fields: fields,
+					}
+					mu.Lock()
+					similarities = append(similarities, similarity)
+					mu.Unlock()
+				}
+			}
+			// Done with this goroutine
+			wg.Done()
+		}(g)
+	}
+
+	// Wait for all goroutines to finish
+	wg.Wait()
+
+	// Sort the slice by score
+	sort.Slice(similarities, func(i, j int) bool {
+		return similarities[i].Score > similarities[j].Score
+	})
+
+	// One column for the combined score and one for each field
+	header := []string{"s1", "s2", "score"}
+	for _, field := range config.Fields {
+		header = append(header, field.Name)
+	}
+	rows := make([][]string, len(similarities))
+	for i, similarity := range similarities {
+		rows[i] = []string{similarity.S1, similarity.S2, fmt.Sprintf("%f", similarity.Score)}
+		for _, field := range config.Fields {
+			rows[i] = append(rows[i], formatFieldScore(similarity.Fields, field.Name))
+		}
+	}
+
+	// Now check if its not set to silent to print results
+	if !BoolFlags["Silent"] {
+		printTable(header, rows)
+	}
+
+	// Check if output to write to file
+	if StringFlags["Output"] != "" {
+		writeTableToFile(StringFlags["Output"], similarities, header, rows)
+	}
+}
@@ -0,0 +1,128 @@
+package similarity
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFieldScore(t *testing.T) {
+	nan := func(string, string) float64 { return math.NaN() }
+
+	cases := []struct {
+		calculate func(string, string) float64
+		v1        string
+		v2        string
+		want      float64
+		ok        bool
+	}{
+		{getSimilarityFunc("jaro"), "adam", "adam", 1, true},
+		{getSimilarityFunc("jaro"), "abc", "xyz", 0, true},
+		{getSimilarityFunc("levenshteinratio"), "kitten", "sitting", 1 - 3.0/7, true},
+		// Empty values are missing, which LevenshteinRatio would score NaN
+		{getSimilarityFunc("levenshteinratio"), "", "", 0, false},
+		{getSimilarityFunc("jaro"), "adam", "", 0, false},
+		{getSimilarityFunc("jaro"), "", "adam", 0, false},
+		{nan, "adam", "adam", 0, false},
+	}
+
+	for _, c := range cases {
+		got, ok := fieldScore(c.calculate, c.v1, c.v2)
+		if ok != c.ok || math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("fieldScore(%q, %q) = %v, %v, want %v, %v", c.v1, c.v2, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestLinkConfigValidate(t *testing.T) {
+	zero, two := 0.0, 2.0
+	config := LinkConfig{Fields: []LinkField{
+		{Name: "name"},
+		{Name: "city", Metric: "LevenshteinRatio", Weight: &zero, Insensitive: true, Normalize: []string{"punctuation", "collapse"}},
+		{Name: "email", Weight: &two, Levels: []float64{0.9}},
+	}}
+	config.validate()
+
+	cases := []struct {
+		field  int
+		metric string
+		weight float64
+		levels []float64
+		value  string
+		want   string
+	}{
+		{0, "jaro", 1, []float64{0.95, 0.85}, "São  Paulo", "São  Paulo"},
+		{1, "levenshteinratio", 0, []float64{0.95, 0.85}, "São,  PAULO!", "são paulo"},
+		{2, "jaro", 2, []float64{0.9}, "A@B.COM", "A@B.COM"},
+	}
+
+	for _, c := range cases {
+		field := config.Fields[c.field]
+		if field.Metric != c.metric || *field.Weight != c.weight || !reflect.DeepEqual(field.Levels, c.levels) {
+			t.Errorf("field %s = %s, %v, %v, want %s, %v, %v", field.Name, field.Metric, *field.Weight, field.Levels, c.metric, c.weight, c.levels)
+		}
+		if got := field.normalizer.Normalize(c.value); got != c.want {
+			t.Errorf("field %s normalizes %q to %q, want %q", field.Name, c.value, got, c.want)
+		}
+	}
+}
+
+// The score of a pair is the weighted mean of its fields, leaving out
+// the missing ones and the ones weighing 0, which are still shown.
+func TestLinkFlow(t *testing.T) {
+	zero, two := 0.0, 2.0
+	config := LinkConfig{Id: "id", Fields: []LinkField{
+		{Name: "name", Metric: "levenshteinratio", Weight: &two, Insensitive: true},
+		{Name: "city", Metric: "levenshteinratio"},
+		{Name: "note", Metric: "jaro", Weight: &zero},
+	}}
+	mainRecords := []map[string]string{
+		{"id": "1", "name": "Adam", "city": "Lisbon", "note": "abc"},
+		{"id": "2", "name": "Eve", "city": "", "note": "abc"},
+	}
+	otherRecords := []map[string]string{
+		{"id": "a", "name": "ADAM", "city": "Lisboa", "note": "xyz"},
+		{"id": "b", "name": "", "city": ""},
+	}
+
+	output := filepath.Join(t.TempDir(), "links.json")
+	LinkFlow(mainRecords, otherRecords, config, math.Inf(-1), 2, map[string]string{"Output": output}, map[string]bool{"Silent": true})
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []RecordSimilarity
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pairs of "b" miss every weighted field, so they have no score
+	want := []RecordSimilarity{
+		{S1: "1", S2: "a", Score: (2*1 + 1*(5.0/6)) / 3, Fields: map[string]float64{"name": 1, "city": 5.0 / 6, "note": 0}},
+		{S1: "2", S2: "a", Score: 0, Fields: map[string]float64{"name": 0, "note": 0}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("LinkFlow linked %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].S1 != want[i].S1 || got[i].S2 != want[i].S2 || math.Abs(got[i].Score-want[i].Score) > 1e-6 || !equalFields(got[i].Fields, want[i].Fields) {
+			t.Errorf("LinkFlow pair %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func equalFields(a map[string]float64, b map[string]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, score := range a {
+		if other, ok := b[name]; !ok || math.Abs(score-other) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package similarity
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// Flow for calculating the full score matrix, one row for each s1
+// and one column for each s2, in the same order as the input.
+// The whole matrix is held in memory, printed to stdout or
+// written to a .csv or .npy file.
+func MatrixFlow(mainStrings []string, otherStrings []string, metric string, amountGoroutines int, normalizer *utils.Normalizer, StringFlags map[string]string, BoolFlags map[string]bool) {
+	// Rows and columns are labeled with the original strings
+	matrix := calculateMatrix(normalizer.NormalizeSlice(mainStrings), normalizer.NormalizeSlice(otherStrings), metric, amountGoroutines)
+
+	// Now check if its not set to silent to print results
+	if !BoolFlags["Silent"] {
+		printMatrix(mainStrings, otherStrings, matrix)
+	}
+
+	// Check if output to write to file
+	if StringFlags["Output"] != "" {
+		writeMatrixToFile(StringFlags["Output"], mainStrings, otherStrings, matrix)
+	}
+}
+
+// Calculates the score of every s1 against every s2 concurrently,
+// returning it as a matrix indexed by [s1][s2]. Skipped pairs
+// are NaN, never passing a threshold.
+func calculateMatrix(mainStrings []string, otherStrings []string, metric string, amountGoroutines int) [][]float64 {
+	calculateSimilarity := getSimilarityFunc(metric)
+	checkPairs(metric, mainStrings, otherStrings)
+
+	// Allocate the matrix up front so every goroutine
+	// can write its own cells without locking
+	matrix := make([][]float64, len(mainStrings))
+	for i := range matrix {
+		matrix[i] = make([]float64, len(otherStrings))
+	}
+
+	var skipped skippedPairs
+	var wg sync.WaitGroup
+
+	// Add the amount of goroutines to the wait group
+	wg.Add(amountGoroutines)
+
+	// Each goroutine takes every 'amountGoroutines'th column,
+	// the same way SliceSplit would split the s2s, but keeping
+	// track of the index to know where to write the score.
+	for g := 0; g < amountGoroutines; g++ {
+		go func(first int) {
+			for j := first; j < len(otherStrings); j += amountGoroutines {
+				for i, s1 := range mainStrings {
+					if skipsPair(metric, s1, otherStrings[j]) {
+						matrix[i][j] = math.NaN()
+						skipped.add(s1, otherStrings[j])
+						continue
+					}
+					matrix[i][j] = calculateSimilarity(s1, otherStrings[j])
+				}
+			}
+			// Done with this goroutine
+			wg.Done()
+		}(g)
+	}
+
+	// Wait for all goroutines to finish
+	wg.Wait()
+	skipped.report(metric)
+
+	return matrix
+}
+
+func printMatrix(mainStrings []string, otherStrings []string, matrix [][]float64) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\t%s\n", strings.Join(otherStrings, "\t"))
+	for i, s1 := range mainStrings {
+		fmt.Fprintf(w, "%s", s1)
+		for _, score := range matrix[i] {
+			fmt.Fprintf(w, "\t%f", score)
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	w.Flush()
+}
+
+// Detect if the matrix output is to csv or npy.
+func writeMatrixToFile(filename string, mainStrings []string, otherStrings []string, matrix [][]float64) {
+	// Check the extension
+	ext := utils.FileExt(filename)
+
+	if ext == ".csv" {
+		writeMatrixToCsv(filename, mainStrings, otherStrings, matrix)
+		return
+	}
+
+	if ext == ".npy" {
+		writeMatrixToNpy(filename, len(mainStrings), len(otherStrings), matrix)
+		return
+	}
+
+	// If the extension is neither .csv nor .npy,
+	// prints error and exit
+	fmt.Println("File extension is not .csv or .npy")
+	os.Exit(1)
+}
+
+// Write the matrix to a csv where the header holds the s2s
+// and the first column of each row holds the s1.
+func writeMatrixToCsv(filename string, mainStrings []string, otherStrings []string, matrix [][]float64) {
+	// Create file
+	file := utils.NewFileWriter(filename)
+	defer closeWriter(file)
+
+	csvWriter := csv.NewWriter(file)
+
+	// Write header, leaving the corner cell empty
+	csvWriter.Write(append([]string{""}, otherStrings...))
+
+	// Write a row for each s1
+	for i, s1 := range mainStrings {
+		row := make([]string, 0, len(otherStrings)+1)
+		row = append(row, s1)
+		for _, score := range matrix[i] {
+			row = append(row, fmt.Sprintf("%f", score))
+		}
+		csvWriter.Write(row)
+	}
+
+	flushCsv(csvWriter)
+}
+
+// Write the matrix as a NumPy .npy file of float64, which can be
+// loaded with numpy.load. The strings themselves are not included.
+func writeMatrixToNpy(filename string, rows int, cols int, matrix [][]float64) {
+	// Create file
+	file := utils.NewFileWriter(filename)
+	defer closeWriter(file)
+
+	// The header is a python dict literal, padded with spaces and
+	// ending in a newline so the data starts aligned to 64 bytes.
+	// The 10 bytes are the magic string, version and header length.
+	header := fmt.Sprintf("{'descr': '<f8', 'fortran_order': False, 'shape': (%d, %d), }", rows, cols)
+	padding := 63 - (10+len(header))%64
+	header = header + strings.Repeat(" ", padding) + "\n"
+
+	file.Write([]byte("\x93NUMPY"))
+	file.Write([]byte{1, 0})
+	binary.Write(file, binary.LittleEndian, uint16(len(header)))
+	file.Write([]byte(header))
+
+	// Data goes row by row in little endian
+	for _, row := range matrix {
+		if err := binary.Write(file, binary.LittleEndian, row); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package similarity
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCalculateMatrix(t *testing.T) {
+	defer SetHammingPolicy("fail")
+
+	cases := []struct {
+		metric string
+		policy string
+		s1s    []string
+		s2s    []string
+		want   [][]float64
+	}{
+		{"levenshtein", "fail", []string{"kitten", "sitting"}, []string{"kitten", "sitting", ""}, [][]float64{{0, 3, 6}, {3, 0, 7}}},
+		{"jaro", "fail", []string{"abc"}, []string{"abc", "xyz"}, [][]float64{{1, 0}}},
+		// Skipped pairs are NaN, never passing a threshold
+		{"hamming", "skip", []string{"abcd"}, []string{"abce", "ab"}, [][]float64{{1, math.NaN()}}},
+		{"hamming", "count", []string{"abcd"}, []string{"abce", "ab"}, [][]float64{{1, 2}}},
+	}
+
+	for _, c := range cases {
+		SetHammingPolicy(c.policy)
+		for _, goroutines := range []int{1, 3} {
+			got := calculateMatrix(c.s1s, c.s2s, c.metric, goroutines)
+			if !equalMatrices(got, c.want) {
+				t.Errorf("calculateMatrix(%q, %q, %s) with %d goroutines = %v, want %v", c.s1s, c.s2s, c.metric, goroutines, got, c.want)
+			}
+		}
+	}
+}
+
+// The file starts with the magic string, version 1.0 and the length
+// of the header, which pads the data to start at a multiple of 64
+// bytes, followed by the scores as little endian float64 by row.
+func TestWriteMatrixToNpy(t *testing.T) {
+	cases := []struct {
+		rows   int
+		cols   int
+		matrix [][]float64
+	}{
+		{2, 3, [][]float64{{0, 0.5, 1}, {math.NaN(), 2, -3}}},
+		{1, 1, [][]float64{{0.25}}},
+		{0, 0, nil},
+	}
+
+	for _, c := range cases {
+		filename := filepath.Join(t.TempDir(), "matrix.npy")
+		writeMatrixToNpy(filename, c.rows, c.cols, c.matrix)
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.HasPrefix(data, []byte("\x93NUMPY\x01\x00")) {
+			t.Errorf("%dx%d matrix starts with %q", c.rows, c.cols, data[:8])
+			continue
+		}
+		headerLength := int(binary.LittleEndian.Uint16(data[8:10]))
+		if (10+headerLength)%64 != 0 {
+			t.Errorf("%dx%d matrix has data starting at %d, not aligned to 64", c.rows, c.cols, 10+headerLength)
+		}
+		header := string(data[10 : 10+headerLength])
+		wantHeader := "{'descr': '<f8', 'fortran_order': False, 'shape': (" + strconv.Itoa(c.rows) + ", " + strconv.Itoa(c.cols) + "), }"
+		if !strings.HasPrefix(header, wantHeader) || !strings.HasSuffix(header, "\n") {
+			t.Errorf("%dx%d matrix has header %q", c.rows, c.cols, header)
+		}
+
+		values := data[10+headerLength:]
+		if len(values) != 8*c.rows*c.cols {
+			t.Errorf("%dx%d matrix has %d bytes of data, want %d", c.rows, c.cols, len(values), 8*c.rows*c.cols)
+			continue
+		}
+		got := make([][]float64, c.rows)
+		for i := range got {
+			got[i] = make([]float64, c.cols)
+			for j := range got[i] {
+				got[i][j] = math.Float64frombits(binary.LittleEndian.Uint64(values[8*(i*c.cols+j):]))
+			}
+		}
+		if !equalMatrices(got, c.matrix) {
+			t.Errorf("%dx%d matrix was written as %v, want %v", c.rows, c.cols, got, c.matrix)
+		}
+	}
+}
+
+// Compares two matrices, where NaN equals NaN.
+func equalMatrices(a [][]float64, b [][]float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j] != b[i][j] && !(math.IsNaN(a[i][j]) && math.IsNaN(b[i][j])) {
+				return false
+			}
+		}
+	}
+	return true
+}
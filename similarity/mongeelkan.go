@@ -0,0 +1,58 @@
+package similarity
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Metric each word is scored with by Monge-Elkan, and whether
+// the score is the mean of both directions.
+var mongeElkanInner = "jarowinkler"
+var mongeElkanSymmetric = false
+
+// Sets the metric Monge-Elkan scores each pair of words with, which has to
+// go from 0 to 1, and whether it's symmetric. Exits on any other metric.
+func SetMongeElkan(inner string, symmetric bool) {
+	inner = strings.ToLower(inner)
+	if !IsRatioMetric(inner) || inner == "mongeelkan" {
+		fmt.Printf("Metric %s can't be the inner metric of MongeElkan. It has to score from 0 to 1, like Jaro, JaroWinkler or LevenshteinRatio\n", inner)
+		os.Exit(1)
+	}
+	mongeElkanInner = inner
+	mongeElkanSymmetric = symmetric
+}
+
+// Monge-Elkan similarity of two strings of many words, the mean over
+// the words of s1 of the score of the best matching word of s2, so
+// each word can have its own typos and the order doesn't matter. The
+// symmetric version is the mean of it both ways.
+func mongeElkan(s1 string, s2 string, calculateSimilarity func(string, string) float64) float64 {
+	words1, words2 := strings.Fields(s1), strings.Fields(s2)
+
+	score := mongeElkanDirection(words1, words2, calculateSimilarity)
+	if mongeElkanSymmetric {
+		score = (score + mongeElkanDirection(words2, words1, calculateSimilarity)) / 2
+	}
+	return score
+}
+
+func mongeElkanDirection(words1 []string, words2 []string, calculateSimilarity func(string, string) float64) float64 {
+	if len(words1) == 0 && len(words2) == 0 {
+		return 1
+	}
+	if len(words1) == 0 || len(words2) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, w1 := range words1 {
+		best := 0.0
+		for _, w2 := range words2 {
+			if score := calculateSimilarity(w1, w2); score > best {
+				best = score
+			}
+		}
+		total += best
+	}
+	return total / float64(len(words1))
+}
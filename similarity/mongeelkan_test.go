@@ -0,0 +1,37 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMongeElkan(t *testing.T) {
+	defer SetMongeElkan("jarowinkler", false)
+
+	cases := []struct {
+		inner     string
+		symmetric bool
+		s1        string
+		s2        string
+		want      float64
+	}{
+		{"levenshteinratio", false, "john smith", "smith john", 1},
+		{"levenshteinratio", false, "john smith", "john smith jr", 1},
+		// "jr" is closest to "john", a LevenshteinRatio of 0.25
+		{"levenshteinratio", false, "john smith jr", "john smith", 0.75},
+		{"levenshteinratio", true, "john smith", "john smith jr", 0.875},
+		{"levenshteinratio", false, "jonh smiht", "smith john", 0.55},
+		{"jaro", false, "abc", "abc xyz", 1},
+		{"jaro", false, "abc", "xyz", 0},
+		{"jaro", false, "", "", 1},
+		{"jaro", true, "abc", "", 0},
+		{"JaroWinkler", false, "  john   smith ", "john smith", 1},
+	}
+
+	for _, c := range cases {
+		SetMongeElkan(c.inner, c.symmetric)
+		if got := getSimilarityFunc("mongeelkan")(c.s1, c.s2); math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("mongeElkan(%q, %q) with %s, symmetric %v = %v, want %v", c.s1, c.s2, c.inner, c.symmetric, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package similarity
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Writers of the compressor of the normalized compression distance,
+// pooled since they are expensive to create, and the compressed length
+// of each s1 already seen, as each one is compared to every s2. Only
+// s1 is cached, since the s1s are all held in memory while the s2s
+// can be streamed from a file larger than it.
+var ncdWriters sync.Pool
+var compressedLengths sync.Map
+
+func init() {
+	SetNCDCompressor("flate")
+}
+
+// Writer of a compressor, which can be reset to write somewhere else.
+type ncdWriter interface {
+	io.WriteCloser
+	Reset(w io.Writer)
+}
+
+// The lzw writer takes its options again when reset.
+type lzwWriter struct {
+	*lzw.Writer
+}
+
+func (w lzwWriter) Reset(dst io.Writer) {
+	w.Writer.Reset(dst, lzw.LSB, 8)
+}
+
+// Sets the compressor of the normalized compression distance, clearing
+// the cached lengths. Exits if it's not flate, gzip, zlib or lzw.
+func SetNCDCompressor(name string) {
+	name = strings.ToLower(name)
+	var newWriter func() ncdWriter
+	switch name {
+	case "flate":
+		newWriter = func() ncdWriter {
+			w, _ := flate.NewWriter(io.Discard, flate.BestCompression)
+			return w
+		}
+	case "gzip":
+		newWriter = func() ncdWriter {
+			w, _ := gzip.NewWriterLevel(io.Discard, gzip.BestCompression)
+			return w
+		}
+	case "zlib":
+		newWriter = func() ncdWriter {
+			w, _ := zlib.NewWriterLevel(io.Discard, zlib.BestCompression)
+			return w
+		}
+	case "lzw":
+		newWriter = func() ncdWriter {
+			return lzwWriter{lzw.NewWriter(io.Discard, lzw.LSB, 8).(*lzw.Writer)}
+		}
+	default:
+		fmt.Println("Compressor not supported. Available: flate, gzip, zlib, lzw")
+		os.Exit(1)
+	}
+
+	ncdWriters = sync.Pool{New: func() interface{} { return newWriter() }}
+	compressedLengths = sync.Map{}
+}
+
+// Counts the bytes written to it, which is all
+// that is needed of the compressed strings.
+type byteCounter struct {
+	n int
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += len(p)
+	return len(p), nil
+}
+
+// Length of the string after compression.
+func compressedLength(s string) int {
+	w := ncdWriters.Get().(ncdWriter)
+	defer ncdWriters.Put(w)
+
+	var counter byteCounter
+	w.Reset(&counter)
+	io.WriteString(w, s)
+	w.Close()
+	return counter.n
+}
+
+// Length of the string after compression, compressing
+// it only the first time it's seen.
+func cachedCompressedLength(s string) int {
+	if n, ok := compressedLengths.Load(s); ok {
+		return n.(int)
+	}
+	n := compressedLength(s)
+	compressedLengths.Store(s, n)
+	return n
+}
+
+// Normalized compression distance, how many more bytes the strings take
+// compressed together than the one that compresses best, relative to the
+// one that compresses worst. Strings that share a lot compress well
+// together and are close to 0, while unrelated ones are close to 1.
+func normalizedCompressionDistance(s1 string, s2 string) float64 {
+	c1 := cachedCompressedLength(s1)
+	c2 := compressedLength(s2)
+	both := compressedLength(s1 + s2)
+
+	shorter, longer := c1, c2
+	if c2 < c1 {
+		shorter, longer = c2, c1
+	}
+	if longer == 0 {
+		return 0
+	}
+	return float64(both-shorter) / float64(longer)
+}
@@ -0,0 +1,39 @@
+package similarity
+
+import (
+	"strings"
+	"testing"
+)
+
+// Compressed lengths differ between compressors, so only how
+// the distances compare to each other is checked.
+func TestNormalizedCompressionDistance(t *testing.T) {
+	defer SetNCDCompressor("flate")
+
+	text := strings.Repeat("the quick brown fox jumps over the lazy dog ", 4)
+	similar := strings.Repeat("the quick brown fox jumps over the lazy cat ", 4)
+	unrelated := strings.Repeat("lorem ipsum dolor sit amet consectetur ", 4)
+
+	for _, compressor := range []string{"flate", "gzip", "zlib", "LZW"} {
+		SetNCDCompressor(compressor)
+
+		if got := normalizedCompressionDistance("", ""); got != 0 {
+			t.Errorf("ncd(%q, %q) with %s = %v, want 0", "", "", compressor, got)
+		}
+
+		same := normalizedCompressionDistance(text, text)
+		near := normalizedCompressionDistance(text, similar)
+		far := normalizedCompressionDistance(text, unrelated)
+		if !(same < far && near < far) {
+			t.Errorf("ncd with %s: identical %v, similar %v, unrelated %v, want the unrelated strings furthest", compressor, same, near, far)
+		}
+		if same < 0 || far > 1.5 {
+			t.Errorf("ncd with %s: identical %v, unrelated %v, want them around 0 and 1", compressor, same, far)
+		}
+
+		// The lengths cached by the previous compressor were cleared
+		if got, want := cachedCompressedLength(text), compressedLength(text); got != want {
+			t.Errorf("cachedCompressedLength with %s = %v, want %v", compressor, got, want)
+		}
+	}
+}
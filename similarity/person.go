@@ -0,0 +1,326 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/antzucaro/matchr"
+	"github.com/mozillazg/go-unidecode"
+)
+
+// Given names that are the same name, a nickname or a common short
+// form of it. A name can be in more than one group, like "ted" being
+// both Edward and Theodore, without Edward and Theodore being equal.
+var nicknameGroups = [][]string{
+	{"william", "bill", "billy", "will", "willy", "willie", "liam"},
+	{"robert", "bob", "bobby", "rob", "robbie", "bert"},
+	{"richard", "rick", "ricky", "dick", "rich", "richie"},
+	{"james", "jim", "jimmy", "jamie"},
+	{"john", "jack", "johnny", "jon"},
+	{"jonathan", "jon", "jonny", "nathan"},
+	{"elizabeth", "liz", "lizzie", "beth", "betty", "bess", "eliza", "lisa", "libby"},
+	{"margaret", "maggie", "meg", "peggy", "marge", "margie", "greta"},
+	{"katherine", "catherine", "kathryn", "kate", "katie", "kathy", "cathy", "kat", "kitty"},
+	{"michael", "mike", "mikey", "mick", "mickey"},
+	{"thomas", "tom", "tommy"},
+	{"joseph", "joe", "joey"},
+	{"josephine", "jo", "josie"},
+	{"charles", "charlie", "chuck", "chas"},
+	{"edward", "ed", "eddie", "ted", "ned"},
+	{"theodore", "ted", "teddy", "theo"},
+	{"anthony", "antony", "tony"},
+	{"daniel", "dan", "danny"},
+	{"david", "dave", "davey"},
+	{"stephen", "steven", "steve", "stevie"},
+	{"christopher", "chris", "kit"},
+	{"christine", "christina", "chris", "chrissy", "tina"},
+	{"matthew", "matt"},
+	{"andrew", "andy", "drew"},
+	{"benjamin", "ben", "benny"},
+	{"samuel", "sam", "sammy"},
+	{"samantha", "sam", "sammy"},
+	{"alexander", "alex", "sandy", "xander"},
+	{"alexandra", "alex", "sandra", "sandy", "lexi"},
+	{"patrick", "pat", "paddy"},
+	{"patricia", "pat", "patty", "trish", "tricia"},
+	{"jennifer", "jen", "jenny"},
+	{"rebecca", "becky", "becca"},
+	{"susan", "sue", "susie", "suzy"},
+	{"deborah", "debra", "deb", "debbie"},
+	{"victoria", "vicky", "tori"},
+	{"nicholas", "nick", "nicky"},
+	{"timothy", "tim", "timmy"},
+	{"kenneth", "ken", "kenny"},
+	{"ronald", "ron", "ronnie"},
+	{"donald", "don", "donnie"},
+	{"gerald", "gerry", "jerry"},
+	{"lawrence", "laurence", "larry"},
+	{"henry", "hank", "harry"},
+	{"harold", "harry", "hal"},
+	{"francis", "frank", "frankie", "fran"},
+	{"frances", "fran", "fanny", "frankie"},
+	{"peter", "pete"},
+	{"gregory", "greg"},
+	{"jeffrey", "geoffrey", "jeff", "geoff"},
+	{"raymond", "ray"},
+	{"eugene", "gene"},
+	{"albert", "al", "bert"},
+	{"alfred", "al", "alfie", "fred"},
+	{"frederick", "fred", "freddie", "freddy"},
+	{"zachary", "zach", "zack"},
+	{"nathaniel", "nathan", "nate", "nat"},
+	{"abigail", "abby", "gail"},
+	{"dorothy", "dot", "dottie", "dolly"},
+	{"barbara", "barb", "babs"},
+	{"kimberly", "kim"},
+	{"pamela", "pam"},
+	{"cynthia", "cindy"},
+	{"sarah", "sara", "sally"},
+	{"mary", "molly", "polly", "mae", "mamie"},
+	{"ann", "anne", "anna", "annie", "nan", "nancy"},
+	{"helen", "nell", "nellie"},
+	{"eleanor", "ellie", "nell", "nora"},
+	{"jacob", "jake"},
+	{"joshua", "josh"},
+	{"leonard", "leo", "len", "lenny"},
+	{"vincent", "vince", "vinny"},
+	{"walter", "walt", "wally"},
+	{"phillip", "philip", "phil"},
+	{"douglas", "doug"},
+	{"jose", "pepe"},
+	{"francisco", "paco", "pancho", "cisco"},
+	{"guillermo", "memo"},
+	{"giuseppe", "beppe", "peppe"},
+}
+
+// Names of each group, after parsing, and the
+// index from each name to the groups it belongs to.
+var nicknameMembers [][]string
+var nicknameIndex = make(map[string][]int)
+
+func init() {
+	AddNicknames(nicknameGroups)
+}
+
+// Adds groups of equivalent given names, like the rows of a csv file
+// read with utils.ReadRowsFromCsvFile, to the ones the person metric
+// knows. Names are compared the same way as in the strings.
+func AddNicknames(groups [][]string) {
+	for _, group := range groups {
+		id := len(nicknameMembers)
+		var members []string
+		for _, name := range group {
+			tokens := personTokens(name)
+			if len(tokens) != 1 {
+				continue
+			}
+			members = append(members, tokens[0])
+			nicknameIndex[tokens[0]] = append(nicknameIndex[tokens[0]], id)
+		}
+		nicknameMembers = append(nicknameMembers, members)
+	}
+}
+
+// Checks if two given names share a nickname group.
+func areNicknames(name1 string, name2 string) bool {
+	for _, g1 := range nicknameIndex[name1] {
+		for _, g2 := range nicknameIndex[name2] {
+			if g1 == g2 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Words ignored when parsing a name.
+var personTitles = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "miss": true, "mx": true, "dr": true,
+	"prof": true, "sir": true, "dame": true, "rev": true,
+	"jr": true, "sr": true, "ii": true, "iii": true, "iv": true,
+	"phd": true, "md": true, "esq": true,
+}
+
+// Words that belong to the family name that follows them,
+// as in "van der Berg" or "da Silva".
+var familyParticles = map[string]bool{
+	"van": true, "von": true, "der": true, "den": true, "de": true, "da": true,
+	"di": true, "del": true, "della": true, "dos": true, "das": true, "do": true,
+	"du": true, "la": true, "le": true, "bin": true, "ibn": true, "al": true,
+	"st": true, "ter": true, "ten": true,
+}
+
+type personName struct {
+	given  []string
+	family string
+	// Parsed with the family name first without a comma saying so
+	swapped bool
+}
+
+// Splits a name into lowercase ASCII words without titles. Periods
+// separate words, so "J.R." is two initials, and apostrophes are
+// dropped. A comma is kept as a word of its own.
+func personTokens(s string) []string {
+	s = strings.ToLower(unidecode.Unidecode(s))
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\'':
+			continue
+		case r == ',':
+			b.WriteString(" , ")
+		case r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+
+	var tokens []string
+	for _, token := range strings.Fields(b.String()) {
+		if !personTitles[token] {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+// The ways a name can be read. With a comma the family name is what
+// comes before it. Without one the family name is the last word, with
+// its particles, or the first word in case the order was swapped.
+func parsePersonName(s string) []personName {
+	tokens := personTokens(s)
+	for i, token := range tokens {
+		if token == "," {
+			var given []string
+			for _, t := range tokens[i+1:] {
+				if t != "," {
+					given = append(given, t)
+				}
+			}
+			return []personName{{given: given, family: strings.Join(tokens[:i], " ")}}
+		}
+	}
+
+	if len(tokens) == 0 {
+		return []personName{{}}
+	}
+	last := len(tokens) - 1
+	for last > 1 && familyParticles[tokens[last-1]] {
+		last--
+	}
+	names := []personName{{given: tokens[:last], family: strings.Join(tokens[last:], " ")}}
+	if len(tokens) > 1 {
+		names = append(names, personName{given: tokens[1:], family: tokens[0], swapped: true})
+	}
+	return names
+}
+
+// Scores two person names from 0 to 1, as the mean of the score of the
+// family names and of the given names, trying the name order swapped.
+// Given names are compared in order, allowing middle names to be
+// missing, nicknames to be equal and initials to match full names.
+func personSimilarity(s1 string, s2 string) float64 {
+	best := 0.0
+	for _, name1 := range parsePersonName(s1) {
+		for _, name2 := range parsePersonName(s2) {
+			score := comparePersonNames(name1, name2)
+			// A swapped order is likely, but less than the usual one
+			if name1.swapped != name2.swapped {
+				score *= 0.95
+			}
+			if score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
+
+func comparePersonNames(name1 personName, name2 personName) float64 {
+	family := 0.0
+	if name1.family == name2.family {
+		family = 1
+	} else if name1.family != "" && name2.family != "" {
+		family = matchr.Jaro(name1.family, name2.family)
+	}
+
+	// Without given names on one side, only the family names are
+	// known to agree, which is weaker evidence of the same person
+	if len(name1.given) == 0 && len(name2.given) == 0 {
+		return family
+	}
+	if len(name1.given) == 0 || len(name2.given) == 0 {
+		return family * 0.8
+	}
+
+	return (family + givenNamesSimilarity(name1.given, name2.given)) / 2
+}
+
+// Best alignment of the given names keeping their order, like a longest
+// common subsequence weighted by the score of each pair of names. The
+// score is the mean over the side with fewer names, with a penalty for
+// each name of the other side left without a pair, so a missing middle
+// name or initial still counts against the match.
+func givenNamesSimilarity(given1 []string, given2 []string) float64 {
+	n, m := len(given1), len(given2)
+	best := make([][]float64, n+1)
+	for i := range best {
+		best[i] = make([]float64, m+1)
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			best[i][j] = best[i-1][j]
+			if best[i][j-1] > best[i][j] {
+				best[i][j] = best[i][j-1]
+			}
+			if aligned := best[i-1][j-1] + givenNameSimilarity(given1[i-1], given2[j-1]); aligned > best[i][j] {
+				best[i][j] = aligned
+			}
+		}
+	}
+
+	shorter := n
+	if m < n {
+		shorter = m
+	}
+	extra := n + m - 2*shorter
+	score := best[n][m]/float64(shorter) - 0.2*float64(extra)
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// Scores a pair of given names. Nicknames score just below equal names,
+// and an initial scores high against a name, or any of its nicknames,
+// starting with it, and 0 against anything else.
+func givenNameSimilarity(name1 string, name2 string) float64 {
+	if name1 == name2 {
+		return 1
+	}
+	if len(name1) == 1 || len(name2) == 1 {
+		initial, full := name1, name2
+		if len(name2) == 1 {
+			initial, full = name2, name1
+		}
+		if len(full) == 1 {
+			return 0
+		}
+		if full[0] == initial[0] {
+			return 0.85
+		}
+		for _, g := range nicknameIndex[full] {
+			for _, nickname := range nicknameMembers[g] {
+				if nickname[0] == initial[0] {
+					return 0.75
+				}
+			}
+		}
+		return 0
+	}
+	if areNicknames(name1, name2) {
+		return 0.95
+	}
+	return matchr.Jaro(name1, name2)
+}
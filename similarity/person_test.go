@@ -0,0 +1,53 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPersonSimilarity(t *testing.T) {
+	cases := []struct {
+		s1   string
+		s2   string
+		want float64
+	}{
+		{"John Smith", "John Smith", 1},
+		{"John Smith", "Smith, John", 1},
+		{"Bill Smith", "William Smith", 0.975},
+		{"J. R. Smith", "John Robert Smith", 0.925},
+		{"J. R. Smith", "Smith, John Robert", 0.925},
+		{"John Smith", "John Robert Smith", 0.9},
+		{"B. Smith", "William Smith", 0.875},
+		{"J. R. Smith", "Jane Smith", 0.825},
+		{"Dr. John Smith Jr.", "John Smith", 1},
+		{"John Smith", "Bill Smith", 0.5},
+		{"John Smith", "", 0},
+	}
+
+	for _, c := range cases {
+		got := personSimilarity(c.s1, c.s2)
+		if math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("personSimilarity(%q, %q) = %v, want %v", c.s1, c.s2, got, c.want)
+		}
+	}
+}
+
+// Initials matching every given name rank above a middle initial left
+// without a pair, and both above a given name that only shares the
+// first letter with them.
+func TestPersonSimilarityOrder(t *testing.T) {
+	ordered := [][2]string{
+		{"J. R. Smith", "John Robert Smith"},
+		{"J. R. Smith", "J. Smith"},
+		{"J. R. Smith", "Jane Smith"},
+		{"J. R. Smith", "Jane Doe"},
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		prev := personSimilarity(ordered[i-1][0], ordered[i-1][1])
+		got := personSimilarity(ordered[i][0], ordered[i][1])
+		if got >= prev {
+			t.Errorf("%q vs %q scores %v, not below the %v of %q vs %q", ordered[i][0], ordered[i][1], got, prev, ordered[i-1][0], ordered[i-1][1])
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package similarity
+
+// Whether characters frequent in s2 are ignored when starting a
+// match, like the autojunk of Python's difflib.SequenceMatcher.
+var autojunk = true
+
+// Sets whether the Ratcliff/Obershelp metric ignores characters that
+// are more than 1% of s2, of at least 200 characters, when looking for
+// matches, like difflib does by default.
+func SetAutojunk(enabled bool) {
+	autojunk = enabled
+}
+
+// Ratcliff/Obershelp similarity, or gestalt pattern matching, giving the
+// same score as ratio() of Python's difflib.SequenceMatcher(None, s1, s2).
+// The longest common block is found, then the ones left and right of
+// it, and so on. The score is twice the matched characters divided by
+// the characters of both strings.
+func ratcliffObershelp(s1 string, s2 string) float64 {
+	a, b := []rune(s1), []rune(s2)
+	if len(a)+len(b) == 0 {
+		return 1
+	}
+
+	// Positions of each character of b, without the popular ones
+	b2j := make(map[rune][]int)
+	for j, c := range b {
+		b2j[c] = append(b2j[c], j)
+	}
+	if autojunk && len(b) >= 200 {
+		limit := len(b)/100 + 1
+		for c, positions := range b2j {
+			if len(positions) > limit {
+				delete(b2j, c)
+			}
+		}
+	}
+
+	// Ranges of a and b still to be matched, taken
+	// from the end like difflib's queue
+	matched := 0
+	queue := [][4]int{{0, len(a), 0, len(b)}}
+	for len(queue) > 0 {
+		r := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		alo, ahi, blo, bhi := r[0], r[1], r[2], r[3]
+
+		i, j, k := findLongestMatch(a, b, b2j, alo, ahi, blo, bhi)
+		if k == 0 {
+			continue
+		}
+		matched += k
+		if alo < i && blo < j {
+			queue = append(queue, [4]int{alo, i, blo, j})
+		}
+		if i+k < ahi && j+k < bhi {
+			queue = append(queue, [4]int{i + k, ahi, j + k, bhi})
+		}
+	}
+
+	return 2 * float64(matched) / float64(len(a)+len(b))
+}
+
+// Longest block of a[alo:ahi] in b[blo:bhi], the first in a and then in b
+// when there's a tie, as in difflib's find_longest_match. Only characters
+// left in b2j can start a block, but a block is then extended with any
+// equal characters around it.
+func findLongestMatch(a []rune, b []rune, b2j map[rune][]int, alo int, ahi int, blo int, bhi int) (int, int, int) {
+	besti, bestj, bestSize := alo, blo, 0
+
+	// Length of the block ending at each position of b,
+	// for the previous character of a
+	j2len := make(map[int]int)
+	for i := alo; i < ahi; i++ {
+		newJ2len := make(map[int]int)
+		for _, j := range b2j[a[i]] {
+			if j < blo {
+				continue
+			}
+			if j >= bhi {
+				break
+			}
+			k := j2len[j-1] + 1
+			newJ2len[j] = k
+			if k > bestSize {
+				besti, bestj, bestSize = i-k+1, j-k+1, k
+			}
+		}
+		j2len = newJ2len
+	}
+
+	for besti > alo && bestj > blo && a[besti-1] == b[bestj-1] {
+		besti, bestj, bestSize = besti-1, bestj-1, bestSize+1
+	}
+	for besti+bestSize < ahi && bestj+bestSize < bhi && a[besti+bestSize] == b[bestj+bestSize] {
+		bestSize++
+	}
+	return besti, bestj, bestSize
+}
@@ -0,0 +1,46 @@
+package similarity
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// Expected scores are difflib.SequenceMatcher(None, s1, s2, autojunk=...).ratio()
+// from Python 3.
+func TestRatcliffObershelp(t *testing.T) {
+	defer SetAutojunk(true)
+
+	cases := []struct {
+		s1       string
+		s2       string
+		autojunk bool
+		want     float64
+	}{
+		{"", "", true, 1},
+		{"abc", "", true, 0},
+		{"", "abc", true, 0},
+		{"apple pie", "apple pies", true, 0.9473684210526315},
+		{"kitten", "sitting", true, 0.6153846153846154},
+		{"abcd", "bcde", true, 0.75},
+		{"São Paulo", "Sao Paulo", true, 0.8888888888888888},
+		{"日本語テキスト", "日本のテキスト", true, 0.8571428571428571},
+		{"naïve café", "naive cafe", true, 0.8},
+		// s2 of at least 200 characters, where "a" and "n" are popular
+		{"banana", strings.Repeat("an", 120), true, 0},
+		{"banana", strings.Repeat("an", 120), false, 0.04065040650406504},
+		{"xbanana", "x" + strings.Repeat("an", 120), true, 0.008064516129032258},
+		{"xbanana", "x" + strings.Repeat("an", 120), false, 0.04838709677419355},
+		// Long, but without popular characters there's nothing to junk
+		{"hello world", strings.Repeat("hello world ", 20), true, 0.08764940239043825},
+		{"hello world", strings.Repeat("hello world ", 20), false, 0.08764940239043825},
+	}
+
+	for _, c := range cases {
+		SetAutojunk(c.autojunk)
+		got := ratcliffObershelp(c.s1, c.s2)
+		if math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("ratcliffObershelp(%q, %q) with autojunk %v = %v, want %v", c.s1, c.s2, c.autojunk, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package similarity
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// A way of preprocessing both strings of a pair before scoring.
+type normalizationOption struct {
+	Name       string
+	steps      []string
+	normalizer *utils.Normalizer
+}
+
+// Every combination of the normalization steps, each applying its
+// steps in the order they were given, starting with none at all.
+func normalizationOptions(steps []string) []normalizationOption {
+	options := []normalizationOption{{Name: "none"}}
+	for _, step := range steps {
+		// Each step doubles the options, added to every one so far
+		for _, option := range options {
+			combined := append(append([]string{}, option.steps...), step)
+			options = append(options, normalizationOption{
+				Name:       strings.Join(combined, " + "),
+				steps:      combined,
+				normalizer: utils.NewNormalizer(combined),
+			})
+		}
+	}
+	return options
+}
+
+type Recommendation struct {
+	Rank          int     `json:"rank"`
+	Metric        string  `json:"metric"`
+	Normalization string  `json:"normalization"`
+	RocAuc        float64 `json:"roc_auc"`
+	PrAuc         float64 `json:"pr_auc"`
+	// Threshold with the highest F1 and its confusion matrix
+	Best ThresholdEvaluation `json:"best"`
+}
+
+// Flow for recommending how to configure a matching job. Evaluates
+// every metric with every combination of the normalization steps
+// against the labeled pairs and ranks the combinations by the best
+// F1 they can reach, with the threshold reaching it and the
+// confusion matrix there.
+func RecommendFlow(pairs []LabeledPair, metrics []string, steps []string, StringFlags map[string]string, BoolFlags map[string]bool) {
+	var recommendations []Recommendation
+	var skipped []string
+	for _, option := range normalizationOptions(steps) {
+		normalized := normalizePairs(pairs, option.normalizer)
+
+		for _, metric := range metrics {
+			if !canScoreAll(metric, normalized) {
+				skipped = append(skipped, fmt.Sprintf("%s with %s", getPrettyMetricName(metric), option.Name))
+				continue
+			}
+			evaluation := evaluateMetric(normalized, metric)
+			recommendations = append(recommendations, Recommendation{
+				Metric:        evaluation.Metric,
+				Normalization: option.Name,
+				RocAuc:        evaluation.RocAuc,
+				PrAuc:         evaluation.PrAuc,
+				Best:          evaluation.Best,
+			})
+		}
+	}
+
+	// Rank by F1, breaking ties by the area under the
+	// precision-recall curve, which rewards a metric that
+	// is good over many thresholds and not only one.
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		if recommendations[i].Best.F1 != recommendations[j].Best.F1 {
+			return recommendations[i].Best.F1 > recommendations[j].Best.F1
+		}
+		return recommendations[i].PrAuc > recommendations[j].PrAuc
+	})
+	for i := range recommendations {
+		recommendations[i].Rank = i + 1
+	}
+
+	header := []string{"rank", "metric", "normalization", "threshold", "f1", "precision", "recall", "pr_auc", "roc_auc", "tp", "fp", "fn", "tn"}
+	rows := make([][]string, len(recommendations))
+	for i, r := range recommendations {
+		rows[i] = []string{
+			strconv.Itoa(r.Rank),
+			r.Metric,
+			r.Normalization,
+			fmt.Sprintf("%f", r.Best.Threshold),
+			fmt.Sprintf("%f", r.Best.F1),
+			fmt.Sprintf("%f", r.Best.Precision),
+			fmt.Sprintf("%f", r.Best.Recall),
+			fmt.Sprintf("%f", r.PrAuc),
+			fmt.Sprintf("%f", r.RocAuc),
+			strconv.Itoa(r.Best.TruePositives),
+			strconv.Itoa(r.Best.FalsePositives),
+			strconv.Itoa(r.Best.FalseNegatives),
+			strconv.Itoa(r.Best.TrueNegatives),
+		}
+	}
+
+	// Now check if its not set to silent to print results, starting
+	// with the recommendation in words and its confusion matrix
+	if !BoolFlags["Silent"] && len(recommendations) > 0 {
+		best := recommendations[0]
+		comparison := "at least"
+		if IsDistanceMetric(strings.ToLower(best.Metric)) {
+			comparison = "at most"
+		}
+		fmt.Printf("Recommended: %s with %s normalization, matching pairs scoring %s %g, gives F1 %.2f (precision %.2f, recall %.2f)\n\n",
+			best.Metric, best.Normalization, comparison, best.Best.Threshold, best.Best.F1, best.Best.Precision, best.Best.Recall)
+		printTable([]string{"", "labeled match", "labeled non-match"}, [][]string{
+			{"predicted match", strconv.Itoa(best.Best.TruePositives), strconv.Itoa(best.Best.FalsePositives)},
+			{"predicted non-match", strconv.Itoa(best.Best.FalseNegatives), strconv.Itoa(best.Best.TrueNegatives)},
+		})
+		fmt.Println()
+		printTable(header, rows)
+	}
+	if !BoolFlags["Silent"] && len(skipped) > 0 {
+		fmt.Printf("\nSkipped, since they can't score every pair: %s\n", strings.Join(skipped, ", "))
+	}
+
+	// Check if output to write to file
+	if StringFlags["Output"] != "" {
+		writeTableToFile(StringFlags["Output"], recommendations, header, rows)
+	}
+}
+
+// Checks if the metric can score every pair, without skipping or
+// failing on any, as Hamming does on strings of different lengths
+// unless its policy pads them or counts the difference.
+func canScoreAll(metric string, pairs []LabeledPair) bool {
+	for _, pair := range pairs {
+		if skipsPair(metric, pair.S1, pair.S2) || failsOnPair(metric, pair.S1, pair.S2) {
+			return false
+		}
+	}
+	return true
+}
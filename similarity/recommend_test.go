@@ -0,0 +1,74 @@
+package similarity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNormalizationOptions(t *testing.T) {
+	cases := []struct {
+		steps []string
+		want  []string
+	}{
+		{nil, []string{"none"}},
+		{[]string{"lowercase"}, []string{"none", "lowercase"}},
+		{[]string{"lowercase", "unidecode", "trim"}, []string{
+			"none",
+			"lowercase",
+			"unidecode",
+			"lowercase + unidecode",
+			"trim",
+			"lowercase + trim",
+			"unidecode + trim",
+			"lowercase + unidecode + trim",
+		}},
+	}
+
+	for _, c := range cases {
+		var got []string
+		for _, option := range normalizationOptions(c.steps) {
+			got = append(got, option.Name)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("normalizationOptions(%q) = %q, want %q", c.steps, got, c.want)
+		}
+	}
+}
+
+// The pairs only separate once the case is ignored, so Levenshtein
+// with the lowercase step is ranked first, and Hamming is left out
+// since it can't score strings of different lengths.
+func TestRecommendFlow(t *testing.T) {
+	pairs := []LabeledPair{
+		{S1: "ADAM", S2: "adam", IsMatch: true},
+		{S1: "Eve", S2: "eve", IsMatch: true},
+		{S1: "adam", S2: "Eva", IsMatch: false},
+		{S1: "EVE", S2: "ada", IsMatch: false},
+	}
+
+	output := filepath.Join(t.TempDir(), "recommendations.json")
+	RecommendFlow(pairs, []string{"levenshtein", "hamming"}, []string{"lowercase"}, map[string]string{"Output": output}, map[string]bool{"Silent": true})
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []Recommendation
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("RecommendFlow = %+v, want Levenshtein with and without lowercase", got)
+	}
+	best := got[0]
+	if best.Rank != 1 || best.Metric != "Levenshtein" || best.Normalization != "lowercase" || best.Best.F1 != 1 || best.Best.Threshold != 0 {
+		t.Errorf("RecommendFlow ranked first %+v, want Levenshtein with lowercase, F1 1 at 0", best)
+	}
+	if got[1].Rank != 2 || got[1].Normalization != "none" || got[1].Best.F1 >= 1 {
+		t.Errorf("RecommendFlow ranked second %+v, want Levenshtein without normalization below F1 1", got[1])
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"sync"
 
 	"github.com/antzucaro/matchr"
+
+	"github.com/mtrentz/stringsim/utils"
 )
 
 type Similarity struct {
@@ -16,6 +18,13 @@ type Similarity struct {
 	S1     string  `json:"s1"`
 	S2     string  `json:"s2"`
 	Score  float64 `json:"score"`
+	// Strings as they were compared, after normalization
+	S1Normalized string `json:"s1_normalized"`
+	S2Normalized string `json:"s2_normalized"`
+	// Only set for the alignment metrics
+	Region *AlignedRegion `json:"region,omitempty"`
+	// Only set with the explain flag
+	Explanation *Explanation `json:"explanation,omitempty"`
 }
 
 // Func that receives the metric name and return a function that
@@ -24,6 +33,10 @@ func getSimilarityFunc(metric string) func(string, string) float64 {
 	switch metric {
 	case "jaro":
 		return matchr.Jaro
+	case "jarowinkler":
+		return func(s1 string, s2 string) float64 {
+			return matchr.JaroWinkler(s1, s2, false)
+		}
 	case "levenshtein":
 		// Wrap the function to return the result as float64
 		return func(s1 string, s2 string) float64 {
@@ -44,16 +57,42 @@ func getSimilarityFunc(metric string) func(string, string) float64 {
 			return float64(matchr.DamerauLevenshtein(s1, s2))
 		}
 	case "hamming":
-		// Wrap the function to return the result as float64
-		// and handle error
+		return hammingDistance
+	case "weightedlevenshtein":
+		return weightedLevenshtein
+	case "weighteddameraulevenshtein":
+		return weightedDamerauLevenshtein
+	case "smithwaterman", "needlemanwunsch", "gotoh":
+		// Keep only the score of the alignment
+		align := getAlignmentFunc(metric)
 		return func(s1 string, s2 string) float64 {
-			score, err := matchr.Hamming(s1, s2)
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-			return float64(score)
+			score, _ := align(s1, s2)
+			return score
+		}
+	case "lcsubstr", "longestcommonsubstring", "lcsubstrratio", "longestcommonsubstringratio":
+		// Keep only the score of the substring
+		findSubstring := getSubstringFunc(metric)
+		return func(s1 string, s2 string) float64 {
+			score, _ := findSubstring(s1, s2)
+			return score
+		}
+	case "lcsratio", "longestcommonsubsequenceratio":
+		return lcsSubsequenceRatio
+	case "ratcliffobershelp":
+		return ratcliffObershelp
+	case "ncd", "normalizedcompressiondistance":
+		return normalizedCompressionDistance
+	case "mongeelkan":
+		// Words are scored with the inner metric,
+		// taken from this same function
+		calculateSimilarity := getSimilarityFunc(mongeElkanInner)
+		return func(s1 string, s2 string) float64 {
+			return mongeElkan(s1, s2, calculateSimilarity)
 		}
+	case "person":
+		return personSimilarity
+	case "address":
+		return addressSimilarity
 	case "lcs", "longestcommonsubsequence":
 		// Longest Common Subsequence
 		// Wrap function to return the result as float64
@@ -67,6 +106,63 @@ func getSimilarityFunc(metric string) func(string, string) float64 {
 	}
 }
 
+// Func that receives the metric name and returns a function scoring
+// two strings, also returning the aligned region for the alignment
+// and longest common substring metrics and nil for the others.
+func getScoringFunc(metric string) func(string, string) (float64, *AlignedRegion) {
+	if IsAlignmentMetric(metric) {
+		return getAlignmentFunc(metric)
+	}
+	if isSubstringMetric(metric) {
+		return getSubstringFunc(metric)
+	}
+	calculateSimilarity := getSimilarityFunc(metric)
+	return func(s1 string, s2 string) (float64, *AlignedRegion) {
+		return calculateSimilarity(s1, s2), nil
+	}
+}
+
+// Names of every supported metric, with their abbreviations,
+// as listed in the help of the commands.
+const MetricNames = "Jaro, JaroWinkler, Levenshtein, LevenshteinRatio, DamerauLevenshtein, Hamming, LongestCommonSubsequence (LCS), LongestCommonSubsequenceRatio (LCSRatio), LongestCommonSubstring (LCSubstr), LongestCommonSubstringRatio (LCSubstrRatio), WeightedLevenshtein, WeightedDamerauLevenshtein, SmithWaterman, NeedlemanWunsch, Gotoh, RatcliffObershelp, MongeElkan, NormalizedCompressionDistance (NCD), Person, Address"
+
+// Every supported metric, each under a single name.
+func AvailableMetrics() []string {
+	return []string{"jaro", "jarowinkler", "levenshtein", "levenshteinratio", "dameraulevenshtein", "hamming", "lcs", "lcsratio", "lcsubstr", "lcsubstrratio", "weightedlevenshtein", "weighteddameraulevenshtein", "smithwaterman", "needlemanwunsch", "gotoh", "ratcliffobershelp", "mongeelkan", "ncd", "person", "address"}
+}
+
+// Metrics that return a distance, where a lower score
+// means the strings are more similar.
+func IsDistanceMetric(metric string) bool {
+	switch metric {
+	case "levenshtein", "dameraulevenshtein", "hamming", "weightedlevenshtein", "weighteddameraulevenshtein", "ncd", "normalizedcompressiondistance":
+		return true
+	default:
+		return false
+	}
+}
+
+// Metrics whose score goes from 0 to 1, so they can be
+// compared and combined across different strings.
+func IsRatioMetric(metric string) bool {
+	switch metric {
+	case "jaro", "jarowinkler", "levenshteinratio", "lcsratio", "longestcommonsubsequenceratio", "lcsubstrratio", "longestcommonsubstringratio", "smithwaterman", "needlemanwunsch", "gotoh", "ratcliffobershelp", "mongeelkan", "person", "address":
+		return true
+	default:
+		return false
+	}
+}
+
+// Checks if a score is within the threshold. For distance metrics
+// the threshold is the maximum distance, for the others it's the
+// minimum score.
+func passesThreshold(metric string, score float64, threshold float64) bool {
+	if IsDistanceMetric(metric) {
+		return score <= threshold
+	}
+	return score >= threshold
+}
+
 // This is mostly for aesthetics to "translate" a
 // metric like "dameraulevenshtein" to "DamerauLevenshtein"
 // since I want the user to be able to input the metric
@@ -75,13 +171,31 @@ func getSimilarityFunc(metric string) func(string, string) float64 {
 func getPrettyMetricName(metric string) string {
 	metric = strings.ToLower(metric)
 	m := map[string]string{
-		"jaro":                     "Jaro",
-		"levenshtein":              "Levenshtein",
-		"levenshteinratio":         "LevenshteinRatio",
-		"dameraulevenshtein":       "DamerauLevenshtein",
-		"hamming":                  "Hamming",
-		"lcs":                      "LongestCommonSubsequence",
-		"longestcommonsubsequence": "LongestCommonSubsequence",
+		"jaro":                          "Jaro",
+		"jarowinkler":                   "JaroWinkler",
+		"levenshtein":                   "Levenshtein",
+		"levenshteinratio":              "LevenshteinRatio",
+		"dameraulevenshtein":            "DamerauLevenshtein",
+		"hamming":                       "Hamming",
+		"lcs":                           "LongestCommonSubsequence",
+		"longestcommonsubsequence":      "LongestCommonSubsequence",
+		"lcsratio":                      "LongestCommonSubsequenceRatio",
+		"longestcommonsubsequenceratio": "LongestCommonSubsequenceRatio",
+		"lcsubstr":                      "LongestCommonSubstring",
+		"longestcommonsubstring":        "LongestCommonSubstring",
+		"lcsubstrratio":                 "LongestCommonSubstringRatio",
+		"longestcommonsubstringratio":   "LongestCommonSubstringRatio",
+		"weightedlevenshtein":           "WeightedLevenshtein",
+		"weighteddameraulevenshtein":    "WeightedDamerauLevenshtein",
+		"smithwaterman":                 "SmithWaterman",
+		"needlemanwunsch":               "NeedlemanWunsch",
+		"gotoh":                         "Gotoh",
+		"ratcliffobershelp":             "RatcliffObershelp",
+		"mongeelkan":                    "MongeElkan",
+		"ncd":                           "NormalizedCompressionDistance",
+		"normalizedcompressiondistance": "NormalizedCompressionDistance",
+		"person":                        "Person",
+		"address":                       "Address",
 	}
 	return m[metric]
 }
@@ -90,10 +204,21 @@ func getPrettyMetricName(metric string) string {
 // exporting output when the amount of calculations is not too
 // high. Output is sorted alphabetically, can be printed to stdout
 // and is written all at once to a file.
-func NormalFlow(mainStrings []string, subSlices [][]string, metric string, amountGoroutines int, StringFlags map[string]string, BoolFlags map[string]bool) {
-	calculateSimilarity := getSimilarityFunc(metric)
+func NormalFlow(mainStrings []string, subSlices [][]string, metric string, amountGoroutines int, normalizer *utils.Normalizer, StringFlags map[string]string, BoolFlags map[string]bool) {
+	scorePair := getScoringFunc(metric)
+
+	// Normalize each string once, comparing the normalized
+	// strings while the output shows the original ones
+	mainNormalized, mainSynonyms := normalizeExplained(mainStrings, normalizer, BoolFlags["Explain"])
+	subSlicesNormalized := make([][]string, len(subSlices))
+	subSlicesSynonyms := make([][][]utils.Substitution, len(subSlices))
+	for k, subSlice := range subSlices {
+		subSlicesNormalized[k], subSlicesSynonyms[k] = normalizeExplained(subSlice, normalizer, BoolFlags["Explain"])
+		checkPairs(metric, mainNormalized, subSlicesNormalized[k])
+	}
 
 	var similarities []Similarity
+	var skipped skippedPairs
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
@@ -102,20 +227,30 @@ func NormalFlow(mainStrings []string, subSlices [][]string, metric string, amoun
 
 	// Start the goroutines
 	// by looping over each subslice
-	for _, subSlice := range subSlices {
+	for k, subSlice := range subSlices {
 		// Create a goroutine for each subslice
-		go func(subSlice []string) {
+		go func(subSlice []string, subSliceNormalized []string, subSliceSynonyms [][]utils.Substitution) {
 			// Calculate the similarities for each subslice
-			for _, s1 := range mainStrings {
-				for _, s2 := range subSlice {
+			for i, s1 := range mainNormalized {
+				for j, s2 := range subSliceNormalized {
+					if skipsPair(metric, s1, s2) {
+						skipped.add(mainStrings[i], subSlice[j])
+						continue
+					}
 					// Calculate the similarity
-					score := calculateSimilarity(s1, s2)
+					score, region := scorePair(s1, s2)
 					// Create a new similarity object
 					similarity := Similarity{
-						Metric: getPrettyMetricName(metric),
-						S1:     s1,
-						S2:     s2,
-						Score:  score,
+						Metric:       getPrettyMetricName(metric),
+						S1:           mainStrings[i],
+						S2:           subSlice[j],
+						Score:        score,
+						S1Normalized: s1,
+						S2Normalized: s2,
+						Region:       region,
+					}
+					if BoolFlags["Explain"] {
+						similarity.Explanation = explainNormalized(metric, s1, s2, mainSynonyms[i], subSliceSynonyms[j])
 					}
 					// Add the similarity to the slice
 					mu.Lock()
@@ -125,7 +260,7 @@ func NormalFlow(mainStrings []string, subSlices [][]string, metric string, amoun
 			}
 			// Done with this goroutine
 			wg.Done()
-		}(subSlice)
+		}(subSlice, subSlicesNormalized[k], subSlicesSynonyms[k])
 	}
 
 	// Wait for all goroutines to finish
@@ -140,6 +275,7 @@ func NormalFlow(mainStrings []string, subSlices [][]string, metric string, amoun
 	if !BoolFlags["Silent"] {
 		printResults(similarities)
 	}
+	skipped.report(metric)
 
 	// Check if output to write to file
 	if StringFlags["Output"] != "" {
@@ -151,26 +287,95 @@ func NormalFlow(mainStrings []string, subSlices [][]string, metric string, amoun
 // Flow for big files, for which I will not hold
 // the similarities slice in memory and I'll
 // be apending each result to the final json file.
-func BigFileFlow(mainStrings []string, subSlices [][]string, metric string, amountGoroutines int, StringFlags map[string]string, BoolFlags map[string]bool) {
-	calculateSimilarity := getSimilarityFunc(metric)
+func BigFileFlow(mainStrings []string, subSlices [][]string, metric string, amountGoroutines int, normalizer *utils.Normalizer, StringFlags map[string]string, BoolFlags map[string]bool) {
+	scorePair := getScoringFunc(metric)
+
+	// Normalize each string once, comparing the normalized
+	// strings while the output shows the original ones
+	mainNormalized, mainSynonyms := normalizeExplained(mainStrings, normalizer, BoolFlags["Explain"])
+	subSlicesNormalized := make([][]string, len(subSlices))
+	subSlicesSynonyms := make([][][]utils.Substitution, len(subSlices))
+	for k, subSlice := range subSlices {
+		subSlicesNormalized[k], subSlicesSynonyms[k] = normalizeExplained(subSlice, normalizer, BoolFlags["Explain"])
+		checkPairs(metric, mainNormalized, subSlicesNormalized[k])
+	}
 
+	var skipped skippedPairs
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
 	// Add the amount of goroutines to the wait group
 	wg.Add(amountGoroutines)
 
+	// Open the output to append each similarity to
+	appendSimilarity, closeOutput := openAppender(StringFlags["Output"])
+	defer closeOutput()
+
+	// Start the goroutines
+	// by looping over each subslice
+	for k, subSlice := range subSlices {
+		// Create a goroutine for each subslice
+		go func(subSlice []string, subSliceNormalized []string, subSliceSynonyms [][]utils.Substitution) {
+			// Calculate the similarities for each subslice
+			for i, s1 := range mainNormalized {
+				for j, s2 := range subSliceNormalized {
+					if skipsPair(metric, s1, s2) {
+						skipped.add(mainStrings[i], subSlice[j])
+						continue
+					}
+					// Calculate the similarity
+					score, region := scorePair(s1, s2)
+					// Create a new similarity object
+					similarity := Similarity{
+						Metric:       getPrettyMetricName(metric),
+						S1:           mainStrings[i],
+						S2:           subSlice[j],
+						Score:        score,
+						S1Normalized: s1,
+						S2Normalized: s2,
+						Region:       region,
+					}
+					if BoolFlags["Explain"] {
+						similarity.Explanation = explainNormalized(metric, s1, s2, mainSynonyms[i], subSliceSynonyms[j])
+					}
+					// Lock the file and append the similarity
+					mu.Lock()
+					appendSimilarity(&similarity)
+					mu.Unlock()
+				}
+			}
+			// Done with this goroutine
+			wg.Done()
+		}(subSlice, subSlicesNormalized[k], subSlicesSynonyms[k])
+	}
+
+	// Wait for all goroutines to finish
+	wg.Wait()
+	skipped.report(metric)
+}
+
+// Opens the output file for the flows that write each similarity
+// as soon as it's calculated. Returns a function that appends a
+// single similarity, only to be called while holding a lock, and
+// a function that closes the output once everything was written.
+func openAppender(filename string) (func(similarity *Similarity), func()) {
+	if utils.CompressionExt(filename) != "" {
+		// Compressed files can't be seeked, so they are
+		// written sequentially and finished at the end.
+		stream := newStreamWriter(filename)
+		return stream.append, stream.close
+	}
+
 	// Create a json file with an empty array or a csv file,
 	// depending on the extension. Will exit if the extension
 	// is not supported.
-	createEmptyFile(StringFlags["Output"])
+	createEmptyFile(filename)
 
 	// Open the file
-	file, err := os.OpenFile(StringFlags["Output"], os.O_RDWR, 0666)
+	file, err := os.OpenFile(filename, os.O_RDWR, 0666)
 	if err != nil {
 		panic(err)
 	}
-	defer file.Close()
 
 	// For a json output, the file will start as [] and I'll be appending
 	// each result to the file.
@@ -179,37 +384,95 @@ func BigFileFlow(mainStrings []string, subSlices [][]string, metric string, amou
 	// `,{"key":value}]`.
 	isEmpty := isEmptyList(file)
 
-	// Start the goroutines
-	// by looping over each subslice
-	for _, subSlice := range subSlices {
-		// Create a goroutine for each subslice
-		go func(subSlice []string) {
-			// Calculate the similarities for each subslice
-			for _, s1 := range mainStrings {
-				for _, s2 := range subSlice {
-					// Calculate the similarity
-					score := calculateSimilarity(s1, s2)
-					// Create a new similarity object
-					similarity := Similarity{
-						Metric: getPrettyMetricName(metric),
-						S1:     s1,
-						S2:     s2,
-						Score:  score,
-					}
-					// Lock the array and append the similarity
-					mu.Lock()
-					appendToFile(file, &similarity, isEmpty)
-					if isEmpty {
-						isEmpty = false
+	appendSimilarity := func(similarity *Similarity) {
+		appendToFile(file, similarity, isEmpty)
+		if isEmpty {
+			isEmpty = false
+		}
+	}
+	closeFile := func() {
+		file.Close()
+	}
+	return appendSimilarity, closeFile
+}
+
+// Flow for when the s2s are streamed from a file that might not
+// fit into memory. Chunks of s2s are handed to the goroutines as
+// they are read and each similarity is appended to the output file.
+func StreamFlow(mainStrings []string, chunks <-chan []string, metric string, amountGoroutines int, normalizer *utils.Normalizer, StringFlags map[string]string, BoolFlags map[string]bool) {
+	scorePair := getScoringFunc(metric)
+
+	// Normalize each string once, comparing the normalized
+	// strings while the output shows the original ones
+	mainNormalized, mainSynonyms := normalizeExplained(mainStrings, normalizer, BoolFlags["Explain"])
+
+	// A pair the metric would stop the run on can only be found
+	// once its chunk is read, so it's kept to be reported after
+	// the goroutines are done and the output is closed
+	var skipped, failed skippedPairs
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Add the amount of goroutines to the wait group
+	wg.Add(amountGoroutines)
+
+	// Open the output to append each similarity to
+	appendSimilarity, closeOutput := openAppender(StringFlags["Output"])
+	defer closeOutput()
+
+	// Start the goroutines, each taking the next
+	// chunk that was read until the file is over
+	for i := 0; i < amountGoroutines; i++ {
+		go func() {
+			for chunk := range chunks {
+				chunkNormalized, chunkSynonyms := normalizeExplained(chunk, normalizer, BoolFlags["Explain"])
+				if !failed.empty() {
+					break
+				}
+				if s1, s2, ok := failingPair(metric, mainNormalized, chunkNormalized); ok {
+					failed.add(s1, s2)
+					break
+				}
+
+				for i, s1 := range mainNormalized {
+					for j, s2 := range chunkNormalized {
+						if skipsPair(metric, s1, s2) {
+							skipped.add(mainStrings[i], chunk[j])
+							continue
+						}
+						// Calculate the similarity
+						score, region := scorePair(s1, s2)
+						// Create a new similarity object
+						similarity := Similarity{
+							Metric:       getPrettyMetricName(metric),
+							S1:           mainStrings[i],
+							S2:           chunk[j],
+							Score:        score,
+							S1Normalized: s1,
+							S2Normalized: s2,
+							Region:       region,
+						}
+						if BoolFlags["Explain"] {
+							similarity.Explanation = explainNormalized(metric, s1, s2, mainSynonyms[i], chunkSynonyms[j])
+						}
+						// Lock the file and append the similarity
+						mu.Lock()
+						appendSimilarity(&similarity)
+						mu.Unlock()
 					}
-					mu.Unlock()
 				}
 			}
 			// Done with this goroutine
 			wg.Done()
-		}(subSlice)
+		}()
 	}
 
 	// Wait for all goroutines to finish
 	wg.Wait()
+	if !failed.empty() {
+		closeOutput()
+		reportFailure(failed.s1, failed.s2)
+		os.Exit(1)
+	}
+	skipped.report(metric)
 }
@@ -0,0 +1,89 @@
+package similarity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/mtrentz/stringsim/utils"
+)
+
+// Reads back the similarities a flow wrote to a json file,
+// sorted by s1 and s2 since goroutines write in any order.
+func readSimilarities(t *testing.T, filename string) []Similarity {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var similarities []Similarity
+	if err := json.Unmarshal(data, &similarities); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(similarities, func(i, j int) bool {
+		if similarities[i].S1 != similarities[j].S1 {
+			return similarities[i].S1 < similarities[j].S1
+		}
+		return similarities[i].S2 < similarities[j].S2
+	})
+	return similarities
+}
+
+// Every flow compares the normalized strings while keeping the
+// originals, and explains the synonyms replaced in each string.
+func TestFlowsKeepOriginalStrings(t *testing.T) {
+	dir := t.TempDir()
+	synonyms := filepath.Join(dir, "synonyms.csv")
+	if err := os.WriteFile(synonyms, []byte("international,intl\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	normalizer := utils.NewNormalizer([]string{"lowercase", "unidecode", "synonyms:" + synonyms})
+
+	mainStrings := []string{"São Paulo Intl"}
+	otherStrings := []string{"SAO PAULO INTERNATIONAL", "Rio"}
+	want := []Similarity{
+		{Metric: "Levenshtein", S1: "São Paulo Intl", S2: "Rio", Score: 20, S1Normalized: "sao paulo international", S2Normalized: "rio", Explanation: &Explanation{
+			Synonyms1: []utils.Substitution{{From: "intl", To: "international"}},
+		}},
+		{Metric: "Levenshtein", S1: "São Paulo Intl", S2: "SAO PAULO INTERNATIONAL", Score: 0, S1Normalized: "sao paulo international", S2Normalized: "sao paulo international", Explanation: &Explanation{
+			Synonyms1: []utils.Substitution{{From: "intl", To: "international"}},
+		}},
+	}
+
+	flows := map[string]func(output string){
+		"normal": func(output string) {
+			NormalFlow(mainStrings, utils.SliceSplit(otherStrings, 2), "levenshtein", 2, normalizer, map[string]string{"Output": output}, map[string]bool{"Silent": true, "Explain": true})
+		},
+		"big file": func(output string) {
+			BigFileFlow(mainStrings, utils.SliceSplit(otherStrings, 2), "levenshtein", 2, normalizer, map[string]string{"Output": output}, map[string]bool{"Silent": true, "Explain": true})
+		},
+		"stream": func(output string) {
+			chunks := make(chan []string, 2)
+			chunks <- otherStrings[:1]
+			chunks <- otherStrings[1:]
+			close(chunks)
+			StreamFlow(mainStrings, chunks, "levenshtein", 2, normalizer, map[string]string{"Output": output}, map[string]bool{"Silent": true, "Explain": true})
+		},
+	}
+
+	for name, flow := range flows {
+		output := filepath.Join(dir, name+".json")
+		flow(output)
+		got := readSimilarities(t, output)
+		if len(got) != len(want) {
+			t.Errorf("%s flow wrote %+v, want %+v", name, got, want)
+			continue
+		}
+		for i := range want {
+			// Only the synonyms of the explanation are checked here
+			if got[i].Explanation != nil {
+				got[i].Explanation = &Explanation{Synonyms1: got[i].Explanation.Synonyms1, Synonyms2: got[i].Explanation.Synonyms2}
+			}
+			if !reflect.DeepEqual(got[i], want[i]) {
+				t.Errorf("%s flow wrote %+v, want %+v", name, got[i], want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package similarity
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/antzucaro/matchr"
+)
+
+// Length the longest common subsequence and substring ratios are
+// divided by, the one of the shorter string, the longer one or the
+// mean of both.
+var lcsNormalization = "max"
+
+// Sets the length the longest common subsequence and substring ratios
+// are divided by. Exits if it's not min, max or mean.
+func SetLCSNormalization(normalization string) {
+	normalization = strings.ToLower(normalization)
+	switch normalization {
+	case "min", "max", "mean":
+		lcsNormalization = normalization
+	default:
+		fmt.Println("LCS normalization not supported. Available: min, max, mean")
+		os.Exit(1)
+	}
+}
+
+// Divides the length of something both strings have in common by
+// the length of the strings, going from 0 to 1.
+func lcsRatio(common int, r1 []rune, r2 []rune) float64 {
+	var length float64
+	switch lcsNormalization {
+	case "min":
+		length = float64(len(r1))
+		if len(r2) < len(r1) {
+			length = float64(len(r2))
+		}
+	case "mean":
+		length = float64(len(r1)+len(r2)) / 2
+	default:
+		length = float64(len(r1))
+		if len(r2) > len(r1) {
+			length = float64(len(r2))
+		}
+	}
+	if length == 0 {
+		if len(r1) == len(r2) {
+			return 1
+		}
+		return 0
+	}
+	return float64(common) / length
+}
+
+// Longest common subsequence divided by the length of the strings.
+func lcsSubsequenceRatio(s1 string, s2 string) float64 {
+	return lcsRatio(matchr.LongestCommonSubsequence(s1, s2), []rune(s1), []rune(s2))
+}
+
+// Metrics of the longest common substring, which also return where
+// the substring is in each string.
+func isSubstringMetric(metric string) bool {
+	switch metric {
+	case "lcsubstr", "longestcommonsubstring", "lcsubstrratio", "longestcommonsubstringratio":
+		return true
+	default:
+		return false
+	}
+}
+
+// Func that receives the metric name and returns a function finding the
+// longest common substring, which returns the score and the region.
+func getSubstringFunc(metric string) func(string, string) (float64, *AlignedRegion) {
+	switch metric {
+	case "lcsubstr", "longestcommonsubstring":
+		return func(s1 string, s2 string) (float64, *AlignedRegion) {
+			region := longestCommonSubstring(s1, s2)
+			return region.RawScore, region
+		}
+	case "lcsubstrratio", "longestcommonsubstringratio":
+		return func(s1 string, s2 string) (float64, *AlignedRegion) {
+			region := longestCommonSubstring(s1, s2)
+			return lcsRatio(int(region.RawScore), []rune(s1), []rune(s2)), region
+		}
+	default:
+		return nil
+	}
+}
+
+// Longest run of characters both strings have, the first one in s1
+// when there's a tie. The region has its length as the raw score.
+func longestCommonSubstring(s1 string, s2 string) *AlignedRegion {
+	r1, r2 := []rune(s1), []rune(s2)
+
+	// Length of the common substring ending at each character,
+	// where only the previous row is needed
+	prev := make([]int, len(r2)+1)
+	cur := make([]int, len(r2)+1)
+	best, bestI, bestJ := 0, 0, 0
+	for i := 1; i <= len(r1); i++ {
+		for j := 1; j <= len(r2); j++ {
+			if r1[i-1] != r2[j-1] {
+				cur[j] = 0
+				continue
+			}
+			cur[j] = prev[j-1] + 1
+			if cur[j] > best {
+				best, bestI, bestJ = cur[j], i, j
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	return &AlignedRegion{
+		Start1:    bestI - best,
+		End1:      bestI,
+		Start2:    bestJ - best,
+		End2:      bestJ,
+		RawScore:  float64(best),
+		Substring: string(r1[bestI-best : bestI]),
+	}
+}
@@ -0,0 +1,57 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLongestCommonSubstring(t *testing.T) {
+	cases := []struct {
+		s1   string
+		s2   string
+		want AlignedRegion
+	}{
+		{"xabcdy", "zabcdw", AlignedRegion{Start1: 1, End1: 5, Start2: 1, End2: 5, RawScore: 4, Substring: "abcd"}},
+		// The first one in s1 wins a tie
+		{"abxcd", "cdxab", AlignedRegion{Start1: 0, End1: 2, Start2: 3, End2: 5, RawScore: 2, Substring: "ab"}},
+		// Offsets are in runes
+		{"São Paulo", "Sao Paulo", AlignedRegion{Start1: 2, End1: 9, Start2: 2, End2: 9, RawScore: 7, Substring: "o Paulo"}},
+		{"abc", "xyz", AlignedRegion{}},
+		{"", "abc", AlignedRegion{}},
+	}
+
+	for _, c := range cases {
+		if got := longestCommonSubstring(c.s1, c.s2); *got != c.want {
+			t.Errorf("longestCommonSubstring(%q, %q) = %+v, want %+v", c.s1, c.s2, *got, c.want)
+		}
+	}
+}
+
+func TestLCSNormalization(t *testing.T) {
+	defer SetLCSNormalization("max")
+
+	cases := []struct {
+		normalization string
+		metric        string
+		s1            string
+		s2            string
+		want          float64
+	}{
+		{"max", "lcsubstrratio", "abc", "abcdef", 0.5},
+		{"min", "lcsubstrratio", "abc", "abcdef", 1},
+		{"mean", "lcsubstrratio", "abc", "abcdef", 3 / 4.5},
+		{"max", "lcsratio", "ace", "abcde", 0.6},
+		{"min", "lcsratio", "ace", "abcde", 1},
+		{"max", "lcsubstr", "ace", "abcde", 1},
+		{"min", "lcsubstrratio", "", "", 1},
+		{"min", "lcsubstrratio", "", "abc", 0},
+		{"MEAN", "lcsratio", "ace", "abcde", 0.75},
+	}
+
+	for _, c := range cases {
+		SetLCSNormalization(c.normalization)
+		if got := getSimilarityFunc(c.metric)(c.s1, c.s2); math.Abs(got-c.want) > 1e-12 {
+			t.Errorf("%s(%q, %q) with %s = %v, want %v", c.metric, c.s1, c.s2, c.normalization, got, c.want)
+		}
+	}
+}
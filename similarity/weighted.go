@@ -0,0 +1,285 @@
+package similarity
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Rows of each keyboard layout, from the number row down, without the
+// keys left of the first key of the row below, so the key c of a row
+// touches the keys c and c+1 of the row above and c-1 and c below.
+var keyboardLayouts = map[string][]string{
+	"qwerty": {"1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./"},
+	"azerty": {"&é\"'(-è_çà)=", "azertyuiop^$", "qsdfghjklmù*", "wxcvbn,;:!"},
+	"qwertz": {"1234567890ß´", "qwertzuiopü+", "asdfghjklöä#", "yxcvbnm,.-"},
+}
+
+// Costs of each edit of the weighted edit distances.
+type EditCosts struct {
+	Insert     float64
+	Delete     float64
+	Substitute float64
+	Transpose  float64
+	// Cost of substituting keys next to each other on the keyboard,
+	// a likely typo, instead of the cost of any other substitution
+	Adjacent float64
+	// Keyboard layout the adjacent keys are taken from, or
+	// "none" to charge every substitution the same
+	Keyboard string
+}
+
+// Every edit costs 1, but typing a key next to the right one on
+// a QWERTY keyboard, which costs half of that.
+var DefaultEditCosts = EditCosts{
+	Insert:     1,
+	Delete:     1,
+	Substitute: 1,
+	Transpose:  1,
+	Adjacent:   0.5,
+	Keyboard:   "qwerty",
+}
+
+// Costs and keys adjacent to each key, for the weighted metrics.
+var editCosts EditCosts
+var adjacentKeys map[rune]map[rune]bool
+
+// Substitution of a sequence of characters by another one, like
+// "rn" by "m", costing something else than its separate edits.
+type substitutionRule struct {
+	from []rune
+	to   []rune
+	cost float64
+}
+
+// Costs of substituting single characters, which replace the usual
+// cost, and rules of longer sequences, keyed by the last character
+// they substitute, read from a substitution cost file.
+var characterCosts = make(map[[2]rune]float64)
+var sequenceRules = make(map[rune][]substitutionRule)
+
+func init() {
+	SetEditCosts(DefaultEditCosts)
+}
+
+// Sets the costs of the weighted edit distances. Exits on negative
+// costs or an unknown keyboard layout.
+func SetEditCosts(costs EditCosts) {
+	for _, cost := range []float64{costs.Insert, costs.Delete, costs.Substitute, costs.Transpose, costs.Adjacent} {
+		if cost < 0 {
+			fmt.Println("Edit costs can't be negative")
+			os.Exit(1)
+		}
+	}
+
+	costs.Keyboard = strings.ToLower(costs.Keyboard)
+	adjacentKeys = make(map[rune]map[rune]bool)
+	if costs.Keyboard != "none" {
+		rows, ok := keyboardLayouts[costs.Keyboard]
+		if !ok {
+			fmt.Println("Keyboard layout not supported. Available: qwerty, azerty, qwertz, none")
+			os.Exit(1)
+		}
+		adjacentKeys = keyboardAdjacency(rows)
+	}
+	editCosts = costs
+}
+
+// Keys touching each key of the layout.
+func keyboardAdjacency(rows []string) map[rune]map[rune]bool {
+	keys := make([][]rune, len(rows))
+	for r, row := range rows {
+		keys[r] = []rune(row)
+	}
+	// Key at a row and column, or 0 outside of the keyboard
+	at := func(r int, c int) rune {
+		if r < 0 || r >= len(keys) || c < 0 || c >= len(keys[r]) {
+			return 0
+		}
+		return keys[r][c]
+	}
+
+	adjacent := make(map[rune]map[rune]bool)
+	for r := range keys {
+		for c, key := range keys[r] {
+			adjacent[key] = make(map[rune]bool)
+			for _, n := range []rune{at(r, c-1), at(r, c+1), at(r-1, c), at(r-1, c+1), at(r+1, c-1), at(r+1, c)} {
+				if n != 0 {
+					adjacent[key][n] = true
+				}
+			}
+		}
+	}
+	return adjacent
+}
+
+// Adds substitution costs from rows like the ones of a csv file, each
+// holding a sequence of characters, the one it's confused with and the
+// cost, as in "0,O,0.1" or "rn,m,0.2". Costs apply both ways and are
+// case sensitive. Exits on rows that can't be read.
+func AddSubstitutionCosts(rows [][]string) {
+	for _, row := range rows {
+		if len(row) != 3 {
+			fmt.Printf("Substitution cost rows need 3 columns, the characters, their substitute and the cost, got %v\n", row)
+			os.Exit(1)
+		}
+		cost, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if err != nil || cost < 0 {
+			fmt.Printf("Invalid substitution cost %s\n", row[2])
+			os.Exit(1)
+		}
+		from, to := []rune(row[0]), []rune(row[1])
+		if len(from) == 0 || len(to) == 0 {
+			fmt.Printf("Substitution cost rows can't have empty characters, got %v\n", row)
+			os.Exit(1)
+		}
+
+		for _, pair := range [2][2][]rune{{from, to}, {to, from}} {
+			if len(pair[0]) == 1 && len(pair[1]) == 1 {
+				characterCosts[[2]rune{pair[0][0], pair[1][0]}] = cost
+				continue
+			}
+			last := pair[0][len(pair[0])-1]
+			sequenceRules[last] = append(sequenceRules[last], substitutionRule{from: pair[0], to: pair[1], cost: cost})
+		}
+	}
+}
+
+// Cost of writing c2 where c1 should be.
+func substitutionCost(c1 rune, c2 rune) float64 {
+	if c1 == c2 {
+		return 0
+	}
+	if cost, ok := characterCosts[[2]rune{c1, c2}]; ok {
+		return cost
+	}
+	if adjacentKeys[unicode.ToLower(c1)][unicode.ToLower(c2)] {
+		return editCosts.Adjacent
+	}
+	return editCosts.Substitute
+}
+
+// Levenshtein distance where each edit has its own cost, with
+// substitutions of adjacent keys and the ones of the substitution
+// cost file being cheaper.
+func weightedLevenshtein(s1 string, s2 string) float64 {
+	r1, r2 := []rune(s1), []rune(s2)
+	return weightedLevenshteinMatrix(r1, r2)[len(r1)][len(r2)]
+}
+
+// Distances between every prefix of s1 and of s2, kept in full since
+// substitutions of sequences reach back more than one row.
+func weightedLevenshteinMatrix(r1 []rune, r2 []rune) [][]float64 {
+	d := make([][]float64, len(r1)+1)
+	for i := range d {
+		d[i] = make([]float64, len(r2)+1)
+		d[i][0] = float64(i) * editCosts.Delete
+	}
+	for j := 1; j <= len(r2); j++ {
+		d[0][j] = float64(j) * editCosts.Insert
+	}
+	distance := func(i int, j int) float64 {
+		return d[i][j]
+	}
+	for i := 1; i <= len(r1); i++ {
+		for j := 1; j <= len(r2); j++ {
+			d[i][j] = math.Min(
+				math.Min(d[i-1][j-1]+substitutionCost(r1[i-1], r2[j-1]), sequenceCost(r1, r2, i, j, distance)),
+				math.Min(d[i-1][j]+editCosts.Delete, d[i][j-1]+editCosts.Insert),
+			)
+		}
+	}
+	return d
+}
+
+// Cheapest way of reaching the first i characters of s1 and j of s2 by
+// substituting a sequence ending there, with the rules of the substitution
+// cost file, given the distance between the shorter prefixes.
+func sequenceCost(r1 []rune, r2 []rune, i int, j int, distance func(int, int) float64) float64 {
+	best := math.Inf(1)
+	for _, rule := range sequenceRules[r1[i-1]] {
+		if len(rule.from) > i || len(rule.to) > j {
+			continue
+		}
+		if !hasRuneSuffix(r1[:i], rule.from) || !hasRuneSuffix(r2[:j], rule.to) {
+			continue
+		}
+		best = math.Min(best, distance(i-len(rule.from), j-len(rule.to))+rule.cost)
+	}
+	return best
+}
+
+func hasRuneSuffix(r []rune, suffix []rune) bool {
+	offset := len(r) - len(suffix)
+	for i, c := range suffix {
+		if r[offset+i] != c {
+			return false
+		}
+	}
+	return true
+}
+
+// Damerau-Levenshtein distance, allowing edits between transposed
+// characters like matchr.DamerauLevenshtein, where each edit has its
+// own cost and substitutions of adjacent keys and the ones of the
+// substitution cost file are cheaper.
+func weightedDamerauLevenshtein(s1 string, s2 string) float64 {
+	r1, r2 := []rune(s1), []rune(s2)
+	return weightedDamerauLevenshteinMatrix(r1, r2)[len(r1)+1][len(r2)+1]
+}
+
+// Distances between every prefix of s1 and of s2, where the distance
+// of the first i characters of s1 and j of s2 is at [i+1][j+1].
+func weightedDamerauLevenshteinMatrix(r1 []rune, r2 []rune) [][]float64 {
+	// Lowrance-Wagner algorithm, with the matrix shifted by one
+	// so row and column 0 can't be the start of a transposition.
+	inf := math.Inf(1)
+	d := make([][]float64, len(r1)+2)
+	for i := range d {
+		d[i] = make([]float64, len(r2)+2)
+	}
+	d[0][0] = inf
+	for i := 0; i <= len(r1); i++ {
+		d[i+1][0] = inf
+		d[i+1][1] = float64(i) * editCosts.Delete
+	}
+	for j := 0; j <= len(r2); j++ {
+		d[0][j+1] = inf
+		d[1][j+1] = float64(j) * editCosts.Insert
+	}
+
+	distance := func(i int, j int) float64 {
+		return d[i+1][j+1]
+	}
+
+	lastRow := make(map[rune]int)
+	for i := 1; i <= len(r1); i++ {
+		lastCol := 0
+		for j := 1; j <= len(r2); j++ {
+			i1 := lastRow[r2[j-1]]
+			j1 := lastCol
+			if r1[i-1] == r2[j-1] {
+				lastCol = j
+			}
+			transposition := weightedTranspositionCost(d, i, j, i1, j1)
+			d[i+1][j+1] = math.Min(
+				math.Min(d[i][j]+substitutionCost(r1[i-1], r2[j-1]), d[i+1][j]+editCosts.Insert),
+				math.Min(d[i][j+1]+editCosts.Delete, transposition),
+			)
+			d[i+1][j+1] = math.Min(d[i+1][j+1], sequenceCost(r1, r2, i, j, distance))
+		}
+		lastRow[r1[i-1]] = i
+	}
+	return d
+}
+
+// Distance of the first i characters of s1 and j of s2 when ending with
+// the transposition of the characters at rows i1 and i, and columns j1
+// and j, of the shifted matrix. Deletes what is between the transposed
+// characters in s1, swaps them and inserts what is between in s2.
+func weightedTranspositionCost(d [][]float64, i int, j int, i1 int, j1 int) float64 {
+	return d[i1][j1] + float64(i-i1-1)*editCosts.Delete + editCosts.Transpose + float64(j-j1-1)*editCosts.Insert
+}
@@ -0,0 +1,91 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWeightedEditDistances(t *testing.T) {
+	defer SetEditCosts(DefaultEditCosts)
+
+	none := DefaultEditCosts
+	none.Keyboard = "none"
+	expensiveInsert := DefaultEditCosts
+	expensiveInsert.Insert = 2
+	azerty := DefaultEditCosts
+	azerty.Keyboard = "azerty"
+
+	cases := []struct {
+		costs       EditCosts
+		s1          string
+		s2          string
+		levenshtein float64
+		damerau     float64
+	}{
+		{DefaultEditCosts, "hello", "hello", 0, 0},
+		// W and J are next to E and H on a QWERTY keyboard
+		{DefaultEditCosts, "hwllo", "hello", 0.5, 0.5},
+		{DefaultEditCosts, "jello", "hello", 0.5, 0.5},
+		{DefaultEditCosts, "hWllo", "hEllo", 0.5, 0.5},
+		{DefaultEditCosts, "axc", "abc", 1, 1},
+		{DefaultEditCosts, "ab", "ba", 2, 1},
+		{DefaultEditCosts, "", "abc", 3, 3},
+		{none, "hwllo", "hello", 1, 1},
+		{expensiveInsert, "ab", "abc", 2, 2},
+		{expensiveInsert, "abc", "ab", 1, 1},
+		// A and Z are next to each other on an AZERTY keyboard
+		{azerty, "zbc", "abc", 0.5, 0.5},
+		{DefaultEditCosts, "zbc", "abc", 0.5, 0.5},
+		{azerty, "qbc", "abc", 0.5, 0.5},
+	}
+
+	for _, c := range cases {
+		SetEditCosts(c.costs)
+		if got := weightedLevenshtein(c.s1, c.s2); math.Abs(got-c.levenshtein) > 1e-12 {
+			t.Errorf("weightedLevenshtein(%q, %q) with %+v = %v, want %v", c.s1, c.s2, c.costs, got, c.levenshtein)
+		}
+		if got := weightedDamerauLevenshtein(c.s1, c.s2); math.Abs(got-c.damerau) > 1e-12 {
+			t.Errorf("weightedDamerauLevenshtein(%q, %q) with %+v = %v, want %v", c.s1, c.s2, c.costs, got, c.damerau)
+		}
+	}
+}
+
+// Costs of the substitution cost file apply both ways, to single
+// characters and to sequences like "rn" read as "m".
+func TestSubstitutionCosts(t *testing.T) {
+	defer func() {
+		characterCosts = make(map[[2]rune]float64)
+		sequenceRules = make(map[rune][]substitutionRule)
+		SetEditCosts(DefaultEditCosts)
+	}()
+	// Without the keyboard, so "0" and "o" aren't adjacent keys
+	costs := DefaultEditCosts
+	costs.Keyboard = "none"
+	SetEditCosts(costs)
+	AddSubstitutionCosts([][]string{{"0", "O", "0.1"}, {"rn", "m", "0.2"}, {"cl", "d", "0.3"}})
+
+	cases := []struct {
+		s1          string
+		s2          string
+		levenshtein float64
+		damerau     float64
+	}{
+		{"0RDER", "ORDER", 0.1, 0.1},
+		{"ORDER", "0RDER", 0.1, 0.1},
+		{"modern", "modem", 0.2, 0.2},
+		{"modem", "modern", 0.2, 0.2},
+		{"clear", "dear", 0.3, 0.3},
+		{"rn", "m", 0.2, 0.2},
+		// Costs are case sensitive
+		{"0rder", "order", 1, 1},
+	}
+
+	for _, c := range cases {
+		if got := weightedLevenshtein(c.s1, c.s2); math.Abs(got-c.levenshtein) > 1e-12 {
+			t.Errorf("weightedLevenshtein(%q, %q) = %v, want %v", c.s1, c.s2, got, c.levenshtein)
+		}
+		if got := weightedDamerauLevenshtein(c.s1, c.s2); math.Abs(got-c.damerau) > 1e-12 {
+			t.Errorf("weightedDamerauLevenshtein(%q, %q) = %v, want %v", c.s1, c.s2, got, c.damerau)
+		}
+	}
+}
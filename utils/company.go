@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Legal forms removed from the end of company names, written the way
+// they usually appear. They go through the same tokenizing as the names,
+// so "S.A." also matches "SA" and "S A", and "Pty. Ltd." matches "Pty Ltd".
+var companySuffixes = []string{
+	// English
+	"Inc", "Incorporated", "Corp", "Corporation", "Co", "Company", "Cos",
+	"LLC", "L.L.C.", "LLP", "L.L.P.", "LP", "L.P.", "Ltd", "Limited", "PLC",
+	"Pty", "Pty Ltd", "Proprietary Limited", "PC", "P.C.", "NL",
+	// German, Austrian and Swiss
+	"GmbH", "GmbH & Co KG", "AG", "KG", "KGaA", "OHG", "GbR", "e.V.", "UG",
+	// French, Belgian and Luxembourgish
+	"SA", "S.A.", "SAS", "S.A.S.", "SASU", "SARL", "S.A.R.L.", "EURL", "SNC", "SCA", "SPRL", "SCS",
+	// Spanish and Latin American
+	"S.L.", "SL", "S.L.U.", "S.A. de C.V.", "S. de R.L.", "S. de R.L. de C.V.", "SRL", "S.R.L.", "S.C.",
+	// Portuguese and Brazilian
+	// ME, of microempresa, is left out since it's also a word, as
+	// in "Call Me Inc"
+	"Ltda", "Limitada", "S/A", "EIRELI", "EPP", "Lda",
+	// Italian
+	"S.p.A.", "SpA", "S.r.l.", "S.a.s.", "S.n.c.",
+	// Dutch and Belgian
+	"BV", "B.V.", "NV", "N.V.", "VOF", "CV",
+	// Nordic
+	"AB", "Aktiebolag", "AS", "A/S", "ASA", "ApS", "Oy", "Oyj", "hf", "ehf",
+	// Central and Eastern European
+	"Sp. z o.o.", "s.r.o.", "a.s.", "d.o.o.", "d.d.", "Kft", "Zrt", "Nyrt", "OOO", "ZAO", "OAO", "PAO",
+	// Asian and others
+	"KK", "K.K.", "Kabushiki Kaisha", "Pte Ltd", "Pte", "Sdn Bhd", "Bhd", "Tbk", "PT", "JSC",
+}
+
+// Words that connect parts of a name in other languages, written as
+// "and" so "Smith & Sons" and "Smith y Sons" become the same. Since
+// they are also letters or words on their own, as in "Vitamin E", they
+// are only connectors between two words of the name.
+var companyConnectors = map[string]bool{
+	"und": true, "et": true, "y": true, "e": true,
+}
+
+// Splits a company name into lowercase words. Periods and apostrophes
+// are dropped, joining "S.A." into "sa", while any other punctuation
+// separates words. "&" and "+" are written as "and".
+func companyTokens(s string) []string {
+	s = cases.Fold().String(norm.NFKC.String(s))
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '.' || r == '\'' || r == '’':
+			continue
+		case r == '&' || r == '+':
+			b.WriteString(" and ")
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			b.WriteRune(' ')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return strings.Fields(b.String())
+}
+
+// Returns a normalization of company names, with the suffixes extended
+// by the ones read from a file. Tokenizes the name, drops a leading
+// "the" and removes legal forms from the end of the name as long as
+// some other word is left. Connectors are written as "and" once the
+// legal forms are gone.
+func companyNormalizer(extraSuffixes []string) func(string) string {
+	var suffixes [][]string
+	for _, suffix := range append(append([]string{}, companySuffixes...), extraSuffixes...) {
+		if tokens := companyTokens(suffix); len(tokens) > 0 {
+			suffixes = append(suffixes, tokens)
+		}
+	}
+
+	return func(s string) string {
+		tokens := companyTokens(s)
+		if len(tokens) > 1 && tokens[0] == "the" {
+			tokens = tokens[1:]
+		}
+
+		// Keep removing the longest suffix at the end, since names
+		// like "Acme Holdings Co., Ltd." have more than one
+		for removed := true; removed; {
+			removed = false
+			longest := 0
+			for _, suffix := range suffixes {
+				if len(suffix) > longest && len(suffix) < len(tokens) && hasTokenSuffix(tokens, suffix) {
+					longest = len(suffix)
+				}
+			}
+			if longest > 0 {
+				tokens = tokens[:len(tokens)-longest]
+				removed = true
+			}
+			// An "and" left dangling at the end, as in "Smith & Co"
+			for len(tokens) > 1 && tokens[len(tokens)-1] == "and" {
+				tokens = tokens[:len(tokens)-1]
+				removed = true
+			}
+		}
+
+		for i := 1; i < len(tokens)-1; i++ {
+			if companyConnectors[tokens[i]] {
+				tokens[i] = "and"
+			}
+		}
+		return strings.Join(tokens, " ")
+	}
+}
+
+func hasTokenSuffix(tokens []string, suffix []string) bool {
+	offset := len(tokens) - len(suffix)
+	for i, token := range suffix {
+		if tokens[offset+i] != token {
+			return false
+		}
+	}
+	return true
+}
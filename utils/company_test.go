@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompanyNormalizer(t *testing.T) {
+	normalize := companyNormalizer(nil)
+
+	cases := []struct {
+		s    string
+		want string
+	}{
+		{"Acme Inc.", "acme"},
+		{"ACME, Inc", "acme"},
+		{"The Acme Company", "acme"},
+		{"Acme Pty Ltd", "acme"},
+		{"Siemens AG", "siemens"},
+		{"Volkswagen GmbH & Co. KG", "volkswagen"},
+		{"Petrobras S/A", "petrobras"},
+		{"Nestlé S.A.", "nestlé"},
+		{"Foo-Bar Ltda.", "foo bar"},
+		{"Tom's Diner LLC", "toms diner"},
+		{"A+B Corp", "a and b"},
+		{"Smith & Sons Ltd", "smith and sons"},
+		{"Smith y Sons S.A.", "smith and sons"},
+		// Connectors are only words between two others
+		{"Vitamin E", "vitamin e"},
+		{"Generation Y", "generation y"},
+		// ME is a word, not the Brazilian legal form
+		{"Call Me Inc", "call me"},
+		// Some word is always left
+		{"Inc", "inc"},
+		{"The", "the"},
+		{"Pty. Ltd.", "pty"},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		if got := normalize(c.s); got != c.want {
+			t.Errorf("company normalization of %q = %q, want %q", c.s, got, c.want)
+		}
+	}
+}
+
+func TestCompanyNormalizerExtraSuffixes(t *testing.T) {
+	suffixes := filepath.Join(t.TempDir(), "suffixes.txt")
+	if err := os.WriteFile(suffixes, []byte("Holdings\nGroup\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	normalizer := NewNormalizer([]string{"company:" + suffixes})
+
+	cases := []struct {
+		s    string
+		want string
+	}{
+		{"Acme Holdings Group Inc.", "acme"},
+		{"Acme Group", "acme"},
+		{"Group", "group"},
+	}
+
+	for _, c := range cases {
+		if got := normalizer.Normalize(c.s); got != c.want {
+			t.Errorf("company normalization of %q = %q, want %q", c.s, got, c.want)
+		}
+	}
+}
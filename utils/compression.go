@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Magic bytes at the start of a gzip and a zstd stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// Returns ".gz" or ".zst" if the filename ends with one of
+// these compression extensions, otherwise an empty string.
+func CompressionExt(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".gz" || ext == ".zst" {
+		return ext
+	}
+	return ""
+}
+
+// Returns the extension of the file ignoring a compression
+// extension, so "strings.txt.gz" returns ".txt".
+func FileExt(filename string) string {
+	if CompressionExt(filename) != "" {
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	return filepath.Ext(filename)
+}
+
+// Adds a suffix to the name of the file, before its extension
+// and any compression extension, so adding "_unmatched" to
+// "output.csv.gz" returns "output_unmatched.csv.gz".
+func AddFileSuffix(filename string, suffix string) string {
+	// Keep the compression extension as it was written, like ".GZ"
+	compression := filename[len(filename)-len(CompressionExt(filename)):]
+	filename = strings.TrimSuffix(filename, compression)
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + suffix + ext + compression
+}
+
+// Opens a file for reading, decompressing it on the fly
+// if it starts with the gzip or zstd magic bytes.
+func openFile(filename string) io.ReadCloser {
+	file, err := os.Open(filename)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Peek at the first bytes without consuming them,
+	// so a plain file can still be read from the start.
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(len(zstdMagic))
+
+	if bytes.HasPrefix(magic, gzipMagic) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return &compressedReader{ReadCloser: gz, file: file}
+	}
+
+	if bytes.HasPrefix(magic, zstdMagic) {
+		zr, err := zstd.NewReader(reader)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return &compressedReader{ReadCloser: zr.IOReadCloser(), file: file}
+	}
+
+	return &compressedReader{ReadCloser: io.NopCloser(reader), file: file}
+}
+
+// Creates a file for writing. If the filename ends in .gz or .zst
+// everything written is compressed accordingly. Closing the writer
+// flushes the compressor and closes the underlying file.
+func NewFileWriter(filename string) io.WriteCloser {
+	file, err := os.Create(filename)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	switch CompressionExt(filename) {
+	case ".gz":
+		return &compressedWriter{WriteCloser: gzip.NewWriter(file), file: file}
+	case ".zst":
+		zw, err := zstd.NewWriter(file)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return &compressedWriter{WriteCloser: zw, file: file}
+	default:
+		return file
+	}
+}
+
+// Decompressing reader that also closes the file it reads from.
+type compressedReader struct {
+	io.ReadCloser
+	file *os.File
+}
+
+func (r *compressedReader) Close() error {
+	r.ReadCloser.Close()
+	return r.file.Close()
+}
+
+// Compressing writer that also closes the file it writes to.
+type compressedWriter struct {
+	io.WriteCloser
+	file *os.File
+}
+
+func (w *compressedWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileExt(t *testing.T) {
+	cases := []struct {
+		filename    string
+		ext         string
+		compression string
+		suffixed    string
+	}{
+		{"strings.txt", ".txt", "", "strings_unmatched.txt"},
+		{"strings.txt.gz", ".txt", ".gz", "strings_unmatched.txt.gz"},
+		{"output.csv.zst", ".csv", ".zst", "output_unmatched.csv.zst"},
+		{"OUTPUT.JSON.GZ", ".JSON", ".gz", "OUTPUT_unmatched.JSON.GZ"},
+		{"dir.v2/matrix.npy", ".npy", "", "dir.v2/matrix_unmatched.npy"},
+		{"archive.gz", "", ".gz", "archive_unmatched.gz"},
+	}
+
+	for _, c := range cases {
+		if got := FileExt(c.filename); got != c.ext {
+			t.Errorf("FileExt(%q) = %q, want %q", c.filename, got, c.ext)
+		}
+		if got := CompressionExt(c.filename); got != c.compression {
+			t.Errorf("CompressionExt(%q) = %q, want %q", c.filename, got, c.compression)
+		}
+		if got := AddFileSuffix(c.filename, "_unmatched"); got != c.suffixed {
+			t.Errorf("AddFileSuffix(%q) = %q, want %q", c.filename, got, c.suffixed)
+		}
+	}
+}
+
+// Files written with NewFileWriter are read back the same,
+// whether they are compressed or not.
+func TestCompressionRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := []struct {
+		filename string
+		content  string
+		want     []string
+	}{
+		{"strings.txt", "adam\nadan\nAden\n", []string{"adam", "adan", "Aden"}},
+		{"strings.txt.gz", "adam\nadan\nAden\n", []string{"adam", "adan", "Aden"}},
+		{"strings.txt.zst", "adam\nadan\nAden\n", []string{"adam", "adan", "Aden"}},
+		{"strings.json.gz", `["São Paulo", "Sao Paulo"]`, []string{"São Paulo", "Sao Paulo"}},
+		{"strings.json.zst", `["São Paulo", "Sao Paulo"]`, []string{"São Paulo", "Sao Paulo"}},
+		{"empty.txt.gz", "", nil},
+	}
+
+	for _, c := range cases {
+		filename := filepath.Join(dir, c.filename)
+		file := NewFileWriter(filename)
+		if _, err := file.Write([]byte(c.content)); err != nil {
+			t.Fatalf("writing %s: %v", c.filename, err)
+		}
+		if err := file.Close(); err != nil {
+			t.Fatalf("closing %s: %v", c.filename, err)
+		}
+
+		if got := ReadFromFile(filename); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ReadFromFile(%q) = %q, want %q", c.filename, got, c.want)
+		}
+	}
+}
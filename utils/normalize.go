@@ -0,0 +1,235 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/mozillazg/go-unidecode"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Words removed by the stopwords step when no file is given.
+var defaultStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "by", "for", "from", "in", "is",
+	"it", "of", "on", "or", "that", "the", "to", "was", "with",
+}
+
+// An ordered list of steps applied to every string before comparing
+// it. A nil Normalizer leaves the strings as they are.
+type Normalizer struct {
+	steps []normalizationStep
+}
+
+type normalizationStep struct {
+	name  string
+	apply func(string) string
+	// Only set for the steps replacing synonyms, doing the
+	// same as apply but also returning what was replaced
+	substitute func(string) (string, []Substitution)
+}
+
+// Builds a Normalizer from the values of the normalize flag. Each value
+// holds steps separated by commas, applied in the order they're given:
+//
+//	lowercase        lower case, what the case insensitive flag does
+//	unidecode        ASCII transliteration, what the unidecode flag does
+//	nfc, nfkc        Unicode normalization forms
+//	casefold         full Unicode case folding
+//	collapse         any run of whitespace becomes a single space
+//	trim             removes leading and trailing whitespace
+//	punctuation      removes punctuation
+//	digits           removes digits
+//	stopwords[:file] removes the words listed in a .txt or .json file,
+//	                 or a short list of English words without a file
+//	company[:file]   company names without legal forms like Inc, GmbH or
+//	                 Ltda, punctuation and a leading "the", with "&" and
+//	                 its variants written as "and". A .txt or .json file
+//	                 adds more legal forms to the built-in ones
+//	synonyms:file    writes the words and phrases of a csv file as the
+//	                 first column of their row, ignoring case
+//	regex:p=>r       replaces matches of the regular expression p with
+//	                 r, which can reference groups as $1. Takes the rest
+//	                 of the value, so the pattern can contain commas
+//
+// Returns nil if there are no steps. Exits on unknown steps.
+func NewNormalizer(values []string) *Normalizer {
+	names := SplitSteps(values)
+	if len(names) == 0 {
+		return nil
+	}
+
+	n := &Normalizer{}
+	for _, name := range names {
+		n.steps = append(n.steps, newNormalizationStep(name))
+	}
+	return n
+}
+
+// Splits the values of the normalize flag into the steps they hold,
+// in order. A regex step takes the rest of its value.
+func SplitSteps(values []string) []string {
+	var names []string
+	for _, value := range values {
+		for value != "" {
+			if strings.HasPrefix(strings.TrimSpace(value), "regex:") {
+				names = append(names, strings.TrimSpace(value))
+				break
+			}
+			name, rest, _ := strings.Cut(value, ",")
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+			value = rest
+		}
+	}
+	return names
+}
+
+func newNormalizationStep(spec string) normalizationStep {
+	name, arg, hasArg := strings.Cut(spec, ":")
+	name = strings.ToLower(name)
+	step := normalizationStep{name: name}
+
+	switch name {
+	case "lowercase":
+		step.apply = strings.ToLower
+	case "unidecode":
+		step.apply = unidecode.Unidecode
+	case "nfc":
+		step.apply = norm.NFC.String
+	case "nfkc":
+		step.apply = norm.NFKC.String
+	case "casefold":
+		step.apply = func(s string) string {
+			// A caser keeps state, so each call gets its own
+			return cases.Fold().String(s)
+		}
+	case "collapse":
+		step.apply = func(s string) string {
+			return strings.Join(strings.FieldsFunc(s, unicode.IsSpace), " ")
+		}
+	case "trim":
+		step.apply = strings.TrimSpace
+	case "punctuation":
+		step.apply = func(s string) string {
+			return strings.Map(dropRune(unicode.IsPunct), s)
+		}
+	case "digits":
+		step.apply = func(s string) string {
+			return strings.Map(dropRune(unicode.IsDigit), s)
+		}
+	case "stopwords":
+		stopwords := defaultStopwords
+		if hasArg {
+			stopwords = ReadFromFile(arg)
+		}
+		step.apply = stopwordRemover(stopwords)
+	case "company":
+		var suffixes []string
+		if hasArg {
+			suffixes = ReadFromFile(arg)
+		}
+		step.apply = companyNormalizer(suffixes)
+	case "synonyms":
+		if !hasArg {
+			fmt.Println("Normalize step synonyms needs a csv file, as in synonyms:synonyms.csv")
+			os.Exit(1)
+		}
+		dictionary := newSynonyms(ReadRowsFromCsvFile(arg))
+		step.substitute = dictionary.replace
+		step.apply = func(s string) string {
+			s, _ = dictionary.replace(s)
+			return s
+		}
+	case "regex":
+		pattern, replacement, _ := strings.Cut(arg, "=>")
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fmt.Printf("Invalid regex in normalize step %s: %s\n", spec, err)
+			os.Exit(1)
+		}
+		step.apply = func(s string) string {
+			return re.ReplaceAllString(s, replacement)
+		}
+	default:
+		fmt.Printf("Unknown normalize step %s. Available: lowercase, unidecode, nfc, nfkc, casefold, collapse, trim, punctuation, digits, stopwords, company, synonyms, regex\n", spec)
+		os.Exit(1)
+	}
+	return step
+}
+
+// Mapping for strings.Map that drops the runes matching f.
+func dropRune(f func(rune) bool) func(rune) rune {
+	return func(r rune) rune {
+		if f(r) {
+			return -1
+		}
+		return r
+	}
+}
+
+// Removes the whole words that are stopwords, ignoring case.
+// Whitespace between the remaining words becomes a single space.
+func stopwordRemover(stopwords []string) func(string) string {
+	set := make(map[string]bool, len(stopwords))
+	for _, word := range stopwords {
+		set[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+	return func(s string) string {
+		words := strings.Fields(s)
+		kept := words[:0]
+		for _, word := range words {
+			if !set[strings.ToLower(word)] {
+				kept = append(kept, word)
+			}
+		}
+		return strings.Join(kept, " ")
+	}
+}
+
+// Applies every step to the string, in order.
+func (n *Normalizer) Normalize(s string) string {
+	if n == nil {
+		return s
+	}
+	for _, step := range n.steps {
+		s = step.apply(s)
+	}
+	return s
+}
+
+// Applies every step to the string, in order, also returning
+// the synonyms replaced along the way.
+func (n *Normalizer) NormalizeWithSubstitutions(s string) (string, []Substitution) {
+	if n == nil {
+		return s, nil
+	}
+	var substitutions []Substitution
+	for _, step := range n.steps {
+		if step.substitute == nil {
+			s = step.apply(s)
+			continue
+		}
+		var replaced []Substitution
+		s, replaced = step.substitute(s)
+		substitutions = append(substitutions, replaced...)
+	}
+	return s, substitutions
+}
+
+// Normalizes every string of the slice into a new slice,
+// so the original strings are kept for the output.
+func (n *Normalizer) NormalizeSlice(slice []string) []string {
+	if n == nil {
+		return slice
+	}
+	normalized := make([]string, len(slice))
+	for i, s := range slice {
+		normalized[i] = n.Normalize(s)
+	}
+	return normalized
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitSteps(t *testing.T) {
+	cases := []struct {
+		values []string
+		want   []string
+	}{
+		{nil, nil},
+		{[]string{"lowercase"}, []string{"lowercase"}},
+		{[]string{"nfkc, casefold,,punctuation ", "trim"}, []string{"nfkc", "casefold", "punctuation", "trim"}},
+		// A regex takes the rest of its value, commas included
+		{[]string{"trim,regex:(\\d+),(\\d+)=>$1.$2", "collapse"}, []string{"trim", "regex:(\\d+),(\\d+)=>$1.$2", "collapse"}},
+		{[]string{"", " , "}, nil},
+	}
+
+	for _, c := range cases {
+		if got := SplitSteps(c.values); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("SplitSteps(%q) = %q, want %q", c.values, got, c.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	dir := t.TempDir()
+	stopwords := filepath.Join(dir, "stopwords.txt")
+	if err := os.WriteFile(stopwords, []byte("ltd\nco\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		steps []string
+		s     string
+		want  string
+	}{
+		{[]string{"lowercase"}, "São PAULO", "são paulo"},
+		{[]string{"unidecode"}, "São Paulo", "Sao Paulo"},
+		{[]string{"lowercase,unidecode"}, "ÁRVORE", "arvore"},
+		// "e" and a combining acute accent become a single "é"
+		{[]string{"nfc"}, "cafe\u0301", "caf\u00e9"},
+		// The "ﬁ" ligature becomes "fi"
+		{[]string{"nfkc"}, "\ufb01le", "file"},
+		{[]string{"casefold"}, "Straße", "strasse"},
+		{[]string{"collapse"}, " a \t b\n\nc ", "a b c"},
+		{[]string{"trim"}, "  a  b  ", "a  b"},
+		{[]string{"punctuation"}, "O'Brien, Jr.", "OBrien Jr"},
+		{[]string{"digits"}, "R2-D2", "R-D"},
+		{[]string{"stopwords"}, "The Bank of  the West", "Bank West"},
+		{[]string{"lowercase,stopwords"}, "The Bank of the West", "bank west"},
+		{[]string{"stopwords:" + stopwords}, "Acme Co Ltd", "Acme"},
+		{[]string{"regex:(\\d+)-(\\d+)=>$2-$1"}, "call 123-456", "call 456-123"},
+		// Steps run in the order given
+		{[]string{"punctuation,collapse"}, "a - b", "a b"},
+		{[]string{"collapse,punctuation"}, "a - b", "a  b"},
+		{nil, " Same ", " Same "},
+	}
+
+	for _, c := range cases {
+		if got := NewNormalizer(c.steps).Normalize(c.s); got != c.want {
+			t.Errorf("Normalize(%q) with %q = %q, want %q", c.s, c.steps, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeWithSubstitutions(t *testing.T) {
+	synonyms := filepath.Join(t.TempDir(), "synonyms.csv")
+	if err := os.WriteFile(synonyms, []byte("international,intl\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		steps         []string
+		s             string
+		want          string
+		substitutions []Substitution
+	}{
+		{[]string{"lowercase", "synonyms:" + synonyms}, "ACME Intl.", "acme international", []Substitution{{From: "intl.", To: "international"}}},
+		{[]string{"punctuation", "synonyms:" + synonyms}, "ACME Intl.", "ACME international", []Substitution{{From: "Intl", To: "international"}}},
+		{[]string{"lowercase"}, "ACME Intl.", "acme intl.", nil},
+		{nil, "ACME Intl.", "ACME Intl.", nil},
+	}
+
+	for _, c := range cases {
+		normalizer := NewNormalizer(c.steps)
+		got, substitutions := normalizer.NormalizeWithSubstitutions(c.s)
+		if got != c.want || !reflect.DeepEqual(substitutions, c.substitutions) {
+			t.Errorf("NormalizeWithSubstitutions(%q) = %q, %v, want %q, %v", c.s, got, substitutions, c.want, c.substitutions)
+		}
+		if normalized := normalizer.Normalize(c.s); normalized != got {
+			t.Errorf("Normalize(%q) = %q, not the %q of NormalizeWithSubstitutions", c.s, normalized, got)
+		}
+	}
+}
+
+func TestNormalizeSlice(t *testing.T) {
+	original := []string{"Adam", "EVE"}
+	got := NewNormalizer([]string{"lowercase"}).NormalizeSlice(original)
+	if want := []string{"adam", "eve"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeSlice = %q, want %q", got, want)
+	}
+	if want := []string{"Adam", "EVE"}; !reflect.DeepEqual(original, want) {
+		t.Errorf("NormalizeSlice changed the original strings to %q", original)
+	}
+	if NewNormalizer(nil) != nil {
+		t.Errorf("NewNormalizer without steps isn't nil")
+	}
+}
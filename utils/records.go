@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Reads records, each a map from field name to value, from a csv file
+// with a header or from a json file as a list of objects. Json values
+// that aren't strings are kept as their json text, so 1990 is "1990".
+func ReadRecordsFromFile(filename string) []map[string]string {
+	// Read from csv file
+	if ext := FileExt(filename); ext == ".csv" {
+		return readRecordsFromCsvFile(filename)
+	}
+
+	// Read from json file
+	if ext := FileExt(filename); ext == ".json" {
+		return readRecordsFromJsonFile(filename)
+	}
+
+	// If file extension not csv or json
+	// prints error and exits.
+	fmt.Println("File extension not .csv or .json")
+	os.Exit(1)
+	return nil
+}
+
+// Reads a csv file where the first row holds the field names.
+func readRecordsFromCsvFile(filename string) []map[string]string {
+	rows := ReadRowsFromCsvFile(filename)
+	if len(rows) == 0 {
+		return nil
+	}
+
+	header := rows[0]
+	records := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := make(map[string]string, len(header))
+		for i, field := range header {
+			// Rows shorter than the header miss the last fields
+			if i < len(row) {
+				record[field] = row[i]
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Reads every row of a csv file, possibly compressed, without a
+// header. Rows can have a different amount of fields.
+func ReadRowsFromCsvFile(filename string) [][]string {
+	if ext := FileExt(filename); ext != ".csv" {
+		fmt.Println("File extension not .csv")
+		os.Exit(1)
+	}
+
+	file := openFile(filename)
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		fmt.Printf("Error reading %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+	return rows
+}
+
+// Reads a json file with a top level list of objects.
+func readRecordsFromJsonFile(filename string) []map[string]string {
+	var arr []map[string]json.RawMessage
+	ReadJsonFile(filename, &arr)
+
+	records := make([]map[string]string, 0, len(arr))
+	for _, obj := range arr {
+		record := make(map[string]string, len(obj))
+		for field, raw := range obj {
+			// Strings are unquoted, anything else kept as is
+			var s string
+			if err := json.Unmarshal(raw, &s); err == nil {
+				record[field] = s
+			} else {
+				record[field] = string(raw)
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Decodes a json file, possibly compressed, into 'v'.
+// Prints the error and exits if it can't be decoded.
+func ReadJsonFile(filename string, v interface{}) {
+	file := openFile(filename)
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(v); err != nil {
+		fmt.Printf("Error reading %s: %v\n", filename, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Reads strings from a txt or json file in chunks of 'chunkSize',
+// sending each chunk to the returned channel as soon as it's read,
+// so the whole file is never held in memory. The channel is
+// closed after the last chunk.
+func StreamFromFile(filename string, chunkSize int) <-chan []string {
+	ext := FileExt(filename)
+	if ext != ".txt" && ext != ".json" {
+		fmt.Println("File extension not .txt or .json")
+		os.Exit(1)
+	}
+
+	chunks := make(chan []string)
+
+	go func() {
+		defer close(chunks)
+
+		if ext == ".txt" {
+			streamFromTxtFile(filename, chunkSize, chunks)
+		} else {
+			streamFromJsonFile(filename, chunkSize, chunks)
+		}
+	}()
+
+	return chunks
+}
+
+// Sends the lines of a txt file in chunks.
+func streamFromTxtFile(filename string, chunkSize int, chunks chan<- []string) {
+	file := openFile(filename)
+	defer file.Close()
+
+	chunk := make([]string, 0, chunkSize)
+	scanner := newLineScanner(file)
+	for scanner.Scan() {
+		chunk = append(chunk, scanner.Text())
+		if len(chunk) == chunkSize {
+			chunks <- chunk
+			chunk = make([]string, 0, chunkSize)
+		}
+	}
+	checkScannerErr(filename, scanner)
+
+	// Send whatever is left
+	if len(chunk) > 0 {
+		chunks <- chunk
+	}
+}
+
+// Sends the strings of a json file, formated as a top level
+// list of strings, in chunks. Decodes one element at a time
+// instead of the whole list.
+func streamFromJsonFile(filename string, chunkSize int, chunks chan<- []string) {
+	file := openFile(filename)
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+
+	// Consume the opening bracket of the list
+	if t, err := decoder.Token(); err != nil || t != json.Delim('[') {
+		fmt.Printf("Error reading %s: expected a json list of strings\n", filename)
+		os.Exit(1)
+	}
+
+	chunk := make([]string, 0, chunkSize)
+	for decoder.More() {
+		var s string
+		if err := decoder.Decode(&s); err != nil {
+			fmt.Printf("Error reading %s: %v\n", filename, err)
+			os.Exit(1)
+		}
+		chunk = append(chunk, s)
+		if len(chunk) == chunkSize {
+			chunks <- chunk
+			chunk = make([]string, 0, chunkSize)
+		}
+	}
+
+	// Send whatever is left
+	if len(chunk) > 0 {
+		chunks <- chunk
+	}
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStreamFromFile(t *testing.T) {
+	dir := t.TempDir()
+
+	cases := []struct {
+		filename  string
+		content   string
+		chunkSize int
+		want      [][]string
+	}{
+		{"strings.txt", "a\nb\nc\nd\ne\n", 2, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+		{"exact.txt", "a\nb\nc\nd\n", 2, [][]string{{"a", "b"}, {"c", "d"}}},
+		{"one.txt", "a\nb\nc\n", 1, [][]string{{"a"}, {"b"}, {"c"}}},
+		{"big.txt", "a\nb\n", 1000, [][]string{{"a", "b"}}},
+		{"empty.txt", "", 2, nil},
+		{"strings.json", `["a", "b", "c"]`, 2, [][]string{{"a", "b"}, {"c"}}},
+		{"empty.json", `[]`, 2, nil},
+	}
+
+	for _, c := range cases {
+		filename := filepath.Join(dir, c.filename)
+		if err := os.WriteFile(filename, []byte(c.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var got [][]string
+		for chunk := range StreamFromFile(filename, c.chunkSize) {
+			got = append(got, chunk)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("StreamFromFile(%q, %d) = %q, want %q", c.filename, c.chunkSize, got, c.want)
+		}
+	}
+}
+
+// Compressed files are streamed the same as plain ones.
+func TestStreamFromCompressedFile(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, filename := range []string{"strings.txt.gz", "strings.txt.zst"} {
+		filename = filepath.Join(dir, filename)
+		file := NewFileWriter(filename)
+		file.Write([]byte("a\nb\nc\n"))
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		var got [][]string
+		for chunk := range StreamFromFile(filename, 2) {
+			got = append(got, chunk)
+		}
+		if want := [][]string{{"a", "b"}, {"c"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("StreamFromFile(%q, 2) = %q, want %q", filename, got, want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// A word or phrase replaced by its synonym while normalizing.
+type Substitution struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Dictionary of words and phrases written as a canonical form, like
+// "intl" and "int'l" written as "international". Phrases are matched
+// word by word, ignoring case and punctuation around the words.
+type synonyms struct {
+	// Canonical form of each phrase, keyed by its words joined by spaces
+	canonical map[string]string
+	// Most words of any phrase, the longest one tried at each word
+	longest int
+}
+
+// Builds the dictionary from rows like the ones of a csv file, where
+// the first column is the canonical form and the others its synonyms.
+// The canonical form is written the way the words are compared, so
+// replacing a phrase with it gives the same string as its synonyms.
+func newSynonyms(rows [][]string) *synonyms {
+	d := &synonyms{canonical: make(map[string]string)}
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		canonical := strings.Join(synonymKeys(strings.Fields(row[0])), " ")
+		if canonical == "" {
+			continue
+		}
+		for _, phrase := range row {
+			words := synonymKeys(strings.Fields(phrase))
+			if len(words) == 0 {
+				continue
+			}
+			d.canonical[strings.Join(words, " ")] = canonical
+			if len(words) > d.longest {
+				d.longest = len(words)
+			}
+		}
+	}
+	return d
+}
+
+// How each word is compared against the dictionary.
+func synonymKeys(words []string) []string {
+	keys := make([]string, 0, len(words))
+	for _, word := range words {
+		key := strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Replaces every phrase of the dictionary found in the string, the
+// longest first, returning the substitutions that changed something.
+// Words are joined by single spaces if anything was replaced.
+func (d *synonyms) replace(s string) (string, []Substitution) {
+	words := strings.Fields(s)
+	keys := make([]string, len(words))
+	for i, word := range words {
+		keys[i] = strings.ToLower(strings.TrimFunc(word, unicode.IsPunct))
+	}
+
+	var substitutions []Substitution
+	var result []string
+	for i := 0; i < len(words); {
+		replaced := false
+		for n := Min(d.longest, len(words)-i); n > 0 && !replaced; n-- {
+			canonical, ok := d.canonical[strings.Join(keys[i:i+n], " ")]
+			if !ok {
+				continue
+			}
+			from := strings.Join(words[i:i+n], " ")
+			if from != canonical {
+				substitutions = append(substitutions, Substitution{From: from, To: canonical})
+			}
+			result = append(result, canonical)
+			i += n
+			replaced = true
+		}
+		if !replaced {
+			result = append(result, words[i])
+			i++
+		}
+	}
+
+	if len(substitutions) == 0 {
+		return s, nil
+	}
+	return strings.Join(result, " "), substitutions
+}
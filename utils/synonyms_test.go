@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSynonymsReplace(t *testing.T) {
+	d := newSynonyms([][]string{
+		{"International", "intl", "int'l"},
+		{"Manufacturing Company.", "mfg co"},
+		{"manufacturing", "mfg"},
+		{""},
+		{},
+	})
+
+	cases := []struct {
+		s             string
+		want          string
+		substitutions []Substitution
+	}{
+		{"Acme Intl. Mfg Co", "Acme international manufacturing company", []Substitution{
+			{From: "Intl.", To: "international"},
+			{From: "Mfg Co", To: "manufacturing company"},
+		}},
+		{"Acme International", "Acme international", []Substitution{{From: "International", To: "international"}}},
+		{"acme international", "acme international", nil},
+		{"mfg  of int'l", "manufacturing of international", []Substitution{
+			{From: "mfg", To: "manufacturing"},
+			{From: "int'l", To: "international"},
+		}},
+		{"  acme  ", "  acme  ", nil},
+		{"", "", nil},
+	}
+
+	for _, c := range cases {
+		got, substitutions := d.replace(c.s)
+		if got != c.want || !reflect.DeepEqual(substitutions, c.substitutions) {
+			t.Errorf("replace(%q) = %q, %v, want %q, %v", c.s, got, substitutions, c.want, c.substitutions)
+		}
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/mozillazg/go-unidecode"
@@ -23,15 +23,16 @@ func CheckForMinimumArgs(cmd *cobra.Command, n int, args []string) {
 }
 
 // Reads strings from a txt file separated by newline
-// or a json file as an array of strings.
+// or a json file as an array of strings. Both can be
+// compressed with gzip or zstd.
 func ReadFromFile(filename string) []string {
 	// Read from txt file
-	if ext := filepath.Ext(filename); ext == ".txt" {
+	if ext := FileExt(filename); ext == ".txt" {
 		return readFromTxtFile(filename)
 	}
 
 	// Read from json file
-	if ext := filepath.Ext(filename); ext == ".json" {
+	if ext := FileExt(filename); ext == ".json" {
 		return readFromJsonFile(filename)
 	}
 
@@ -42,21 +43,22 @@ func ReadFromFile(filename string) []string {
 	return nil
 }
 
+// Maximum length in bytes of a single line read from a txt file.
+// Reading a longer line is an error instead of a silent truncation.
+var MaxLineLength = 1024 * 1024
+
 // Reads all lines from a txt file and returns them as a list.
 func readFromTxtFile(filename string) []string {
 	// Open file
-	file, err := os.Open(filename)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	file := openFile(filename)
 
 	// Read file
 	var lines []string
-	scanner := bufio.NewScanner(file)
+	scanner := newLineScanner(file)
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
 	}
+	checkScannerErr(filename, scanner)
 
 	// Close file
 	file.Close()
@@ -64,21 +66,41 @@ func readFromTxtFile(filename string) []string {
 	return lines
 }
 
+// Creates a scanner that reads line by line and
+// accepts lines up to MaxLineLength bytes. The scanner takes the
+// larger of its buffer's capacity and the maximum as the limit, so
+// the buffer can't start bigger than the maximum.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, Min(MaxLineLength, bufio.MaxScanTokenSize)), MaxLineLength)
+	return scanner
+}
+
+// Prints the error that stopped a scanner, if any, and exits.
+func checkScannerErr(filename string, scanner *bufio.Scanner) {
+	err := scanner.Err()
+	if err == nil {
+		return
+	}
+	if err == bufio.ErrTooLong {
+		fmt.Printf("Error reading %s: line longer than %d bytes\n", filename, MaxLineLength)
+	} else {
+		fmt.Printf("Error reading %s: %v\n", filename, err)
+	}
+	os.Exit(1)
+}
+
 // Read from a json file that is a list of strings and returns them as a list.
 func readFromJsonFile(filename string) []string {
 	// Expecting a json file with a top level list of only strings
 	var arr []string
 
 	// Read content from files and unmarshal into struct
-	file, err := os.Open(filename)
-	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	file := openFile(filename)
 	defer file.Close()
 
 	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&arr)
+	err := decoder.Decode(&arr)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -115,6 +137,13 @@ func Min(a, b int) int {
 	return b
 }
 
+func Max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
 // Pass all strings in a slice of strings to lower case
 func SliceToLower(slice *[]string) {
 	for i, s := range *slice {